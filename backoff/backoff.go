@@ -0,0 +1,60 @@
+// Package backoff повторяет операцию с экспоненциально растущей паузой.
+package backoff
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy - правила повторов: пауза начинается с Initial, удваивается после
+// каждой неудачной попытки и не превышает Max. Attempts - общее число попыток.
+type Policy struct {
+	Initial  time.Duration
+	Max      time.Duration
+	Attempts int
+}
+
+// Delay возвращает паузу после неудачной попытки attempt (с 1): случайное
+// значение от половины до целой экспоненциальной паузы, чтобы несколько
+// экземпляров не повторяли запросы одновременно.
+func (p Policy) Delay(attempt int) time.Duration {
+	d := p.Initial
+	for i := 1; i < attempt && d < p.Max; i++ {
+		d *= 2
+	}
+	d = min(d, p.Max)
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + rand.N(d-half+1)
+}
+
+// Retry вызывает fn, пока она не вернет nil, не кончатся попытки или не
+// отменится ctx, и возвращает последнюю ошибку fn или ошибку ctx.
+// Перед каждой паузой вызывается notify, если он не nil.
+func Retry(ctx context.Context, p Policy, fn func(ctx context.Context) error, notify func(attempt int, delay time.Duration, err error)) error {
+	attempts := max(p.Attempts, 1)
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= attempts {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		delay := p.Delay(attempt)
+		if notify != nil {
+			notify(attempt, delay, err)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Тестирование роста паузы и ее верхней границы
+func TestDelay(t *testing.T) {
+	p := Policy{Initial: 100 * time.Millisecond, Max: time.Second}
+
+	tests := []struct {
+		attempt int
+		full    time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{50, time.Second},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			d := p.Delay(tt.attempt)
+			assert.GreaterOrEqual(t, d, tt.full/2, "attempt %d", tt.attempt)
+			assert.LessOrEqual(t, d, tt.full, "attempt %d", tt.attempt)
+		}
+	}
+}
+
+// Тестирование повторов до успеха, до исчерпания попыток и до отмены
+func TestRetry(t *testing.T) {
+	p := Policy{Initial: time.Millisecond, Max: 2 * time.Millisecond, Attempts: 4}
+	errDown := errors.New("connection refused")
+
+	calls := 0
+	var notified []int
+	err := Retry(context.Background(), p, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errDown
+		}
+		return nil
+	}, func(attempt int, delay time.Duration, err error) {
+		notified = append(notified, attempt)
+		assert.Equal(t, errDown, err)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []int{1, 2}, notified)
+
+	calls = 0
+	err = Retry(context.Background(), p, func(ctx context.Context) error {
+		calls++
+		return errDown
+	}, nil)
+	assert.Equal(t, errDown, err)
+	assert.Equal(t, 4, calls)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls = 0
+	err = Retry(ctx, Policy{Initial: time.Hour, Max: time.Hour, Attempts: 3}, func(ctx context.Context) error {
+		calls++
+		cancel()
+		return errDown
+	}, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, calls)
+}
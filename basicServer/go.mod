@@ -1,5 +0,0 @@
-module basicServer
-
-go 1.23.2
-
-require github.com/gorilla/mux v1.8.1 // indirect
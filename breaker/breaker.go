@@ -0,0 +1,239 @@
+// Package breaker реализует автомат защиты (circuit breaker): после серии
+// отказов зависимости вызовы к ней не выполняются, пока не пройдет пауза,
+// а затем пропускаются пробные вызовы.
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// State - состояние автомата.
+type State int
+
+const (
+	// Closed - вызовы выполняются, отказы подряд считаются.
+	Closed State = iota
+	// Open - вызовы сразу получают ErrOpen до конца паузы.
+	Open
+	// HalfOpen - выполняются только пробные вызовы; их успех закрывает
+	// автомат, первый отказ снова открывает.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// MarshalText выводит состояние строкой в JSON.
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Outcome - итог разрешенного вызова.
+type Outcome int
+
+const (
+	// Success - зависимость ответила.
+	Success Outcome = iota
+	// Failure - вызов не удался из-за зависимости.
+	Failure
+	// Ignored - вызов ничего не сказал о зависимости, например отменен
+	// клиентом до ответа. Не считается ни успехом, ни отказом; в HalfOpen
+	// освобождает место для следующего пробного вызова.
+	Ignored
+)
+
+// ErrOpen - вызов отклонен без обращения к зависимости.
+var ErrOpen = errors.New("автомат защиты разомкнут")
+
+// OpenError - вызов отклонен; RetryAfter - сколько осталось до пробных вызовов.
+// errors.Is(err, ErrOpen) == true.
+type OpenError struct {
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string { return ErrOpen.Error() }
+
+func (e *OpenError) Is(target error) bool { return target == ErrOpen }
+
+// Settings - настройки автомата. Нулевые значения заменяются значениями по умолчанию.
+type Settings struct {
+	// FailureThreshold - число отказов подряд, после которого автомат
+	// открывается, по умолчанию 5.
+	FailureThreshold int
+	// OpenTimeout - сколько автомат остается открытым, по умолчанию 30 секунд.
+	OpenTimeout time.Duration
+	// HalfOpenRequests - сколько пробных вызовов должно пройти успешно,
+	// чтобы закрыть автомат; больше одновременно не пропускается. По умолчанию 1.
+	HalfOpenRequests int
+	// Now - источник времени, по умолчанию time.Now. Тесты подставляют свои часы.
+	Now func() time.Time
+	// OnStateChange вызывается при смене состояния, может быть nil.
+	// Вызывается под мьютексом автомата и не должен обращаться к нему.
+	OnStateChange func(from, to State)
+}
+
+// Breaker - автомат защиты. Безопасен для одновременного использования.
+type Breaker struct {
+	settings Settings
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	// generation растет при каждой смене состояния: результаты вызовов,
+	// начатых в прошлом состоянии, не учитываются.
+	generation uint64
+	openedAt   time.Time
+	// trials и successes - пробные вызовы в HalfOpen: выполняются и успешные.
+	trials    int
+	successes int
+}
+
+// New создает закрытый автомат.
+func New(s Settings) *Breaker {
+	if s.FailureThreshold <= 0 {
+		s.FailureThreshold = 5
+	}
+	if s.OpenTimeout <= 0 {
+		s.OpenTimeout = 30 * time.Second
+	}
+	if s.HalfOpenRequests <= 0 {
+		s.HalfOpenRequests = 1
+	}
+	if s.Now == nil {
+		s.Now = time.Now
+	}
+	return &Breaker{settings: s}
+}
+
+// Allow разрешает вызов или возвращает *OpenError. После разрешенного
+// вызова нужно вызвать done ровно один раз с его итогом: Failure - только
+// если вызов не удался из-за зависимости (а не, например, из-за неверных данных).
+func (b *Breaker) Allow() (done func(Outcome), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.settings.Now()
+	if b.state == Open {
+		if wait := b.openedAt.Add(b.settings.OpenTimeout).Sub(now); wait > 0 {
+			return nil, &OpenError{RetryAfter: wait}
+		}
+		b.setState(HalfOpen, now)
+	}
+	if b.state == HalfOpen {
+		if b.trials >= b.settings.HalfOpenRequests {
+			return nil, &OpenError{}
+		}
+		b.trials++
+	}
+
+	generation := b.generation
+	var once sync.Once
+	return func(outcome Outcome) {
+		once.Do(func() { b.record(generation, outcome) })
+	}, nil
+}
+
+// Do выполняет fn, если автомат разрешает вызов. Любая ошибка fn считается отказом.
+func (b *Breaker) Do(fn func() error) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+	err = fn()
+	if err != nil {
+		done(Failure)
+	} else {
+		done(Success)
+	}
+	return err
+}
+
+func (b *Breaker) record(generation uint64, outcome Outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if generation != b.generation {
+		return
+	}
+	if outcome == Ignored {
+		if b.state == HalfOpen {
+			b.trials--
+		}
+		return
+	}
+	failed := outcome == Failure
+
+	now := b.settings.Now()
+	switch b.state {
+	case Closed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.settings.FailureThreshold {
+			b.setState(Open, now)
+		}
+	case HalfOpen:
+		if failed {
+			b.setState(Open, now)
+			return
+		}
+		b.successes++
+		if b.successes >= b.settings.HalfOpenRequests {
+			b.setState(Closed, now)
+		}
+	}
+}
+
+// setState вызывается под b.mu.
+func (b *Breaker) setState(to State, now time.Time) {
+	from := b.state
+	b.state = to
+	b.generation++
+	b.failures, b.trials, b.successes = 0, 0, 0
+	if to == Open {
+		b.openedAt = now
+	}
+	if b.settings.OnStateChange != nil {
+		b.settings.OnStateChange(from, to)
+	}
+}
+
+// Snapshot - состояние автомата для проверки состояния сервиса.
+type Snapshot struct {
+	State State `json:"state"`
+	// Failures - отказы подряд в состоянии Closed.
+	Failures int `json:"failures"`
+	// RetryAfter - сколько осталось до пробных вызовов в состоянии Open.
+	RetryAfter time.Duration `json:"-"`
+	// RetryAfterSeconds - то же в секундах для JSON.
+	RetryAfterSeconds float64 `json:"retry_after_seconds,omitempty"`
+}
+
+// Snapshot возвращает текущее состояние. Открытый автомат, у которого
+// истекла пауза, показывается как HalfOpen.
+func (b *Breaker) Snapshot() Snapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := Snapshot{State: b.state, Failures: b.failures}
+	if b.state == Open {
+		wait := b.openedAt.Add(b.settings.OpenTimeout).Sub(b.settings.Now())
+		if wait <= 0 {
+			return Snapshot{State: HalfOpen}
+		}
+		s.RetryAfter, s.RetryAfterSeconds = wait, wait.Seconds()
+	}
+	return s
+}
@@ -0,0 +1,150 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock - часы, которые двигаются только вручную.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+var errDown = errors.New("connection refused")
+
+func fail() error { return errDown }
+
+func ok() error { return nil }
+
+// Тестирование переходов closed -> open -> half-open -> closed
+func TestBreaker(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	var transitions []string
+	b := New(Settings{
+		FailureThreshold: 3,
+		OpenTimeout:      10 * time.Second,
+		Now:              clock.Now,
+		OnStateChange: func(from, to State) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+	})
+
+	// успех сбрасывает счетчик отказов подряд
+	assert.Equal(t, errDown, b.Do(fail))
+	assert.Equal(t, errDown, b.Do(fail))
+	assert.NoError(t, b.Do(ok))
+	assert.Equal(t, 0, b.Snapshot().Failures)
+
+	for range 3 {
+		assert.Equal(t, errDown, b.Do(fail))
+	}
+	assert.Equal(t, Open, b.Snapshot().State)
+
+	// открытый автомат не вызывает fn
+	clock.Advance(4 * time.Second)
+	called := false
+	err := b.Do(func() error { called = true; return nil })
+	assert.False(t, called)
+	var openErr *OpenError
+	require.ErrorAs(t, err, &openErr)
+	assert.ErrorIs(t, err, ErrOpen)
+	assert.Equal(t, 6*time.Second, openErr.RetryAfter)
+	assert.Equal(t, 6.0, b.Snapshot().RetryAfterSeconds)
+
+	// после паузы пробный вызов; неудача снова открывает
+	clock.Advance(6 * time.Second)
+	assert.Equal(t, HalfOpen, b.Snapshot().State)
+	assert.Equal(t, errDown, b.Do(fail))
+	assert.Equal(t, Open, b.Snapshot().State)
+	assert.ErrorIs(t, b.Do(ok), ErrOpen)
+
+	// успешный пробный вызов закрывает
+	clock.Advance(10 * time.Second)
+	assert.NoError(t, b.Do(ok))
+	assert.Equal(t, Closed, b.Snapshot().State)
+
+	assert.Equal(t, []string{
+		"closed->open",
+		"open->half-open",
+		"half-open->open",
+		"open->half-open",
+		"half-open->closed",
+	}, transitions)
+}
+
+// Тестирование ограничения пробных вызовов и устаревших результатов
+func TestBreakerHalfOpen(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	b := New(Settings{FailureThreshold: 1, OpenTimeout: time.Second, HalfOpenRequests: 2, Now: clock.Now})
+
+	// вызов начат до открытия, его результат не учитывается
+	stale, err := b.Allow()
+	require.NoError(t, err)
+	assert.Equal(t, errDown, b.Do(fail))
+	stale(Success)
+	assert.Equal(t, Open, b.Snapshot().State)
+
+	clock.Advance(time.Second)
+	first, err := b.Allow()
+	require.NoError(t, err)
+	second, err := b.Allow()
+	require.NoError(t, err)
+	_, err = b.Allow()
+	assert.ErrorIs(t, err, ErrOpen)
+
+	first(Success)
+	first(Failure) // повторный done игнорируется
+	assert.Equal(t, HalfOpen, b.Snapshot().State)
+	second(Success)
+	assert.Equal(t, Closed, b.Snapshot().State)
+}
+
+// Тестирование вызовов, отмененных до ответа зависимости
+func TestBreakerIgnored(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	b := New(Settings{FailureThreshold: 2, OpenTimeout: time.Second, Now: clock.Now})
+
+	// в Closed отмена не сбрасывает счетчик отказов
+	assert.Equal(t, errDown, b.Do(fail))
+	done, err := b.Allow()
+	require.NoError(t, err)
+	done(Ignored)
+	assert.Equal(t, 1, b.Snapshot().Failures)
+	assert.Equal(t, errDown, b.Do(fail))
+	assert.Equal(t, Open, b.Snapshot().State)
+
+	// отмененный пробный вызов не закрывает автомат и освобождает место
+	clock.Advance(time.Second)
+	trial, err := b.Allow()
+	require.NoError(t, err)
+	_, err = b.Allow()
+	assert.ErrorIs(t, err, ErrOpen)
+	trial(Ignored)
+	assert.Equal(t, HalfOpen, b.Snapshot().State)
+
+	assert.NoError(t, b.Do(ok))
+	assert.Equal(t, Closed, b.Snapshot().State)
+}
+
+// Тестирование значений по умолчанию и имен состояний в JSON
+func TestBreakerDefaults(t *testing.T) {
+	b := New(Settings{})
+	for range 4 {
+		b.Do(fail)
+	}
+	assert.Equal(t, Closed, b.Snapshot().State)
+	b.Do(fail)
+	assert.Equal(t, Open, b.Snapshot().State)
+
+	text, err := HalfOpen.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "half-open", string(text))
+}
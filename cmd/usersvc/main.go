@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+
+	"lab8/backoff"
+	"lab8/breaker"
+	"lab8/config"
+	"lab8/requestid"
+	"lab8/server"
+	"lab8/store"
+)
+
+// Коды завершения процесса.
+const (
+	exitOK = 0
+	// exitError - ошибка конфигурации, запуска или работы сервера.
+	exitError = 1
+	// exitForced - запросы не завершились за shutdown_timeout и были прерваны.
+	exitForced = 2
+)
+
+// version задается при сборке: go build -ldflags "-X main.version=1.2.3".
+var version = "dev"
+
+// connectDB подключается к MongoDB и ждет ее доступности: Connect не ходит
+// в сеть, поэтому доступность проверяется Ping с повторами по cfg.ConnectAttempts.
+// После запуска переподключением занимается драйвер, а состояние - monitor.
+func connectDB(ctx context.Context, cfg config.MongoConfig, monitor *store.MongoMonitor) (*mongo.Client, error) {
+	client, err := mongo.Connect(ctx, options.Client().
+		ApplyURI(cfg.URI).
+		SetHeartbeatInterval(cfg.HeartbeatInterval).
+		SetServerMonitor(monitor.ServerMonitor()))
+	if err != nil {
+		return nil, err
+	}
+
+	policy := backoff.Policy{Initial: cfg.ConnectBackoff, Max: cfg.ConnectBackoffMax, Attempts: cfg.ConnectAttempts}
+	err = backoff.Retry(ctx, policy, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout)
+		defer cancel()
+		return client.Ping(ctx, readpref.Primary())
+	}, func(attempt int, delay time.Duration, err error) {
+		slog.Warn("MongoDB недоступна", "attempt", attempt, "attempts", cfg.ConnectAttempts,
+			"retry_in", delay.Round(time.Millisecond), "err", err)
+	})
+	if err != nil {
+		client.Disconnect(context.Background())
+		return nil, fmt.Errorf("MongoDB недоступна: %w", err)
+	}
+	slog.Info("Подключение к бд успешно", "database", cfg.Database)
+	return client, nil
+}
+
+func disconnectDB(client *mongo.Client, cfg config.MongoConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
+	return client.Disconnect(ctx)
+}
+
+func migrate(s *store.MongoStore, cfg config.MongoConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
+	n, skipped, err := s.MigrateAges(ctx)
+	if err != nil {
+		return err
+	}
+	slog.Info("Миграция age выполнена", "modified", n)
+	if skipped > 0 {
+		slog.Warn("Строковые age, которые не удалось перевести в число, оставлены как есть", "documents", skipped)
+	}
+	return nil
+}
+
+// checkAges предупреждает о документах со строковым age: до миграции
+// они читаются, но не попадают под фильтры по возрасту.
+func checkAges(s *store.MongoStore, cfg config.MongoConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
+	n, err := s.StringAges(ctx)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		slog.Warn("В коллекции есть строковые age, выполните --migrate", "documents", n)
+	}
+	return nil
+}
+
+func ensureIndexes(s *store.MongoStore, cfg config.MongoConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
+	return s.EnsureIndexes(ctx)
+}
+
+// newLogger создает журнал в формате cfg.Format в stderr. Записи с
+// контекстом запроса получают поле request_id.
+func newLogger(cfg config.LogConfig) *slog.Logger {
+	var level slog.Level
+	// уровень уже проверен в config.Validate
+	level.UnmarshalText([]byte(cfg.Level))
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler = slog.NewTextHandler(os.Stderr, opts)
+	if cfg.Format == "json" {
+		h = slog.NewJSONHandler(os.Stderr, opts)
+	}
+	return slog.New(requestid.NewHandler(h))
+}
+
+// serve обслуживает запросы, пока ctx не отменен. Затем переводит /readyz
+// в 503 и ждет delay, перестает принимать соединения и ждет текущие
+// запросы не дольше grace. Служебный сервер admin (может быть nil)
+// останавливается последним. Возвращает код завершения.
+func serve(ctx context.Context, srv, admin *http.Server, api *server.Server, delay, grace time.Duration) int {
+	errs := make(chan error, 2)
+	go func() {
+		errs <- srv.ListenAndServe()
+	}()
+	if admin != nil {
+		go func() {
+			errs <- admin.ListenAndServe()
+		}()
+	}
+
+	select {
+	case err := <-errs:
+		slog.Error("Ошибка сервера", "err", err)
+		return exitError
+	case <-ctx.Done():
+	}
+
+	api.Drain()
+	if delay > 0 {
+		slog.Info("Остановка: /readyz отвечает 503", "drain_delay", delay)
+		time.Sleep(delay)
+	}
+
+	slog.Info("Остановка: ждем завершения запросов", "shutdown_timeout", grace)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	if admin != nil {
+		defer admin.Close()
+	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Запросы не завершились вовремя, соединения закрыты", "err", err)
+		srv.Close()
+		return exitForced
+	}
+	slog.Info("Все запросы завершены")
+	return exitOK
+}
+
+func main() {
+	os.Exit(run())
+}
+
+func run() (code int) {
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if err != nil {
+		slog.Error("Ошибка конфигурации", "err", err)
+		return exitError
+	}
+
+	if cfg.PrintConfig {
+		out, err := cfg.YAML()
+		if err != nil {
+			slog.Error("Ошибка вывода конфигурации", "err", err)
+			return exitError
+		}
+		os.Stdout.Write(out)
+		return exitOK
+	}
+
+	logger := newLogger(cfg.Log)
+	// через slog.Default идет и стандартный log, например ошибки http.Server;
+	// у таких записей нет контекста запроса и поля request_id
+	slog.SetDefault(logger)
+
+	// после первого сигнала stop возвращает обычную обработку,
+	// и повторный Ctrl+C завершает процесс сразу
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	var (
+		s      store.UserStore
+		checks []server.HealthCheck
+	)
+	switch cfg.Store {
+	case "memory":
+		gen, err := store.NewIDGenerator(cfg.Memory.IDGenerator)
+		if err != nil {
+			slog.Error("Ошибка конфигурации", "err", err)
+			return exitError
+		}
+		now := time.Now().UTC()
+		s = store.NewMemoryStore(gen,
+			store.User{ID: gen.NewID(), Name: "Виктор", Age: 21, CreatedAt: now, Version: 1},
+			store.User{ID: gen.NewID(), Name: "Аркадий", Age: 45, CreatedAt: now, Version: 1},
+		)
+	case "mongo":
+		codec, err := store.NewIDCodec(cfg.Mongo.IDType)
+		if err != nil {
+			slog.Error("Ошибка конфигурации", "err", err)
+			return exitError
+		}
+		monitor := store.NewMongoMonitor(cfg.Mongo.HeartbeatInterval, func(available bool, err error) {
+			if available {
+				slog.Info("MongoDB доступна")
+			} else {
+				slog.Error("MongoDB недоступна", "err", err)
+			}
+		})
+		client, err := connectDB(ctx, cfg.Mongo, monitor)
+		if err != nil {
+			slog.Error("Ошибка подключения к бд", "err", err)
+			return exitError
+		}
+		defer func() {
+			if err := disconnectDB(client, cfg.Mongo); err != nil {
+				slog.Error("Ошибка отключения от бд", "err", err)
+				if code == exitOK {
+					code = exitError
+				}
+			}
+		}()
+		cb := breaker.New(breaker.Settings{
+			FailureThreshold: cfg.Mongo.BreakerFailures,
+			OpenTimeout:      cfg.Mongo.BreakerOpenTimeout,
+			HalfOpenRequests: cfg.Mongo.BreakerHalfOpenRequests,
+			OnStateChange: func(from, to breaker.State) {
+				slog.Warn("Автомат защиты MongoDB", "from", from, "to", to)
+			},
+		})
+		mongoStore := store.NewMongoStore(client.Database(cfg.Mongo.Database).Collection(cfg.Mongo.Collection), store.MongoOptions{
+			IDs:     codec,
+			Monitor: monitor,
+			Breaker: cb,
+		})
+		if cfg.Migrate {
+			if err := migrate(mongoStore, cfg.Mongo); err != nil {
+				slog.Error("Ошибка миграции", "err", err)
+				return exitError
+			}
+			return exitOK
+		}
+		if err := ensureIndexes(mongoStore, cfg.Mongo); err != nil {
+			slog.Error("Ошибка создания индексов", "err", err)
+			return exitError
+		}
+		if err := checkAges(mongoStore, cfg.Mongo); err != nil {
+			slog.Error("Ошибка проверки age", "err", err)
+			return exitError
+		}
+		s = mongoStore
+		checks = append(checks, server.HealthCheck{
+			Name:  "mongo",
+			Check: mongoStore.Ping,
+			Details: func() any {
+				available, since := monitor.Available()
+				return map[string]any{"available": available, "since": since, "breaker": cb.Snapshot()}
+			},
+		})
+	}
+
+	api := server.New(s, server.Options{
+		Timeout:       cfg.Timeout,
+		RouteTimeouts: cfg.RouteTimeouts,
+		ListEnvelope:  cfg.ListEnvelope,
+		CursorSecret:  []byte(cfg.CursorSecret),
+		MaxBodyBytes:  cfg.MaxBodyBytes,
+		Version:       version,
+		StoreType:     cfg.Store,
+		Checks:        checks,
+		AccessLog: server.AccessLog{
+			Logger:     logger,
+			SampleRate: cfg.Log.SampleRate,
+			Redact:     cfg.Log.Redact,
+		},
+	})
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           api.Router(),
+		ReadHeaderTimeout: cfg.ReadTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+
+	var admin *http.Server
+	if cfg.AdminAddr != "" {
+		admin = &http.Server{
+			Addr:              cfg.AdminAddr,
+			Handler:           server.AdminRouter(),
+			ReadHeaderTimeout: cfg.ReadTimeout,
+			ReadTimeout:       cfg.ReadTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		}
+		slog.Info("Служебный сервер запущен", "addr", cfg.AdminAddr)
+	}
+
+	//GET http://localhost:8080/users?name=alice&limit=5&page=2
+	slog.Info("Сервер запущен", "addr", cfg.Addr, "store", cfg.Store, "version", version)
+	// соединение с Mongo закрывается в defer, уже после завершения запросов
+	return serve(ctx, srv, admin, api, cfg.DrainDelay, cfg.ShutdownTimeout)
+}
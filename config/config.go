@@ -0,0 +1,586 @@
+// Package config собирает настройки сервиса из нескольких слоев:
+// значения по умолчанию < файл YAML/TOML < переменные окружения < флаги.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix - префикс переменных окружения, например USERSVC_MONGO_URI.
+const EnvPrefix = "USERSVC_"
+
+type Config struct {
+	Addr string `yaml:"addr" toml:"addr"`
+	// AdminAddr - адрес служебного сервера с /debug/vars; пустой - выключен.
+	// Не должен быть доступен снаружи.
+	AdminAddr string        `yaml:"admin_addr" toml:"admin_addr"`
+	Store     string        `yaml:"store" toml:"store"`
+	Timeout   time.Duration `yaml:"timeout" toml:"timeout"`
+	// RouteTimeouts - таймауты отдельных маршрутов (list, get, create, update,
+	// patch, delete) вместо Timeout. Слои объединяются по маршрутам.
+	RouteTimeouts map[string]time.Duration `yaml:"route_timeouts,omitempty" toml:"route_timeouts"`
+	// ReadTimeout, WriteTimeout и IdleTimeout - таймауты соединений http.Server.
+	ReadTimeout  time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	// ShutdownTimeout - сколько ждать завершения запросов при остановке.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	// DrainDelay - сколько /readyz отвечает 503 перед остановкой, чтобы
+	// балансировщик успел убрать экземпляр.
+	DrainDelay time.Duration `yaml:"drain_delay" toml:"drain_delay"`
+	// ListEnvelope - отдавать GET /users объектом с items, total и total_pages.
+	ListEnvelope bool `yaml:"list_envelope" toml:"list_envelope"`
+	// CursorSecret - ключ подписи курсоров; если пуст, создается при запуске.
+	CursorSecret string `yaml:"cursor_secret" toml:"cursor_secret"`
+	// MaxBodyBytes - максимальный размер тела запроса в байтах.
+	MaxBodyBytes int64        `yaml:"max_body_bytes" toml:"max_body_bytes"`
+	Log          LogConfig    `yaml:"log" toml:"log"`
+	Memory       MemoryConfig `yaml:"memory" toml:"memory"`
+	Mongo        MongoConfig  `yaml:"mongo" toml:"mongo"`
+
+	// PrintConfig - вывести итоговую конфигурацию и завершиться.
+	PrintConfig bool `yaml:"-" toml:"-"`
+	// Migrate - выполнить миграции данных в MongoDB и завершиться.
+	Migrate bool `yaml:"-" toml:"-"`
+}
+
+type LogConfig struct {
+	// Format - формат записей: text или json.
+	Format string `yaml:"format" toml:"format"`
+	// Level - минимальный уровень: debug, info, warn или error.
+	Level string `yaml:"level" toml:"level"`
+	// SampleRate - доля успешных запросов в журнале запросов, от 0 до 1;
+	// ответы 4xx и 5xx пишутся всегда.
+	SampleRate float64 `yaml:"sample_rate" toml:"sample_rate"`
+	// Redact - параметры запроса, значения которых не попадают в журнал.
+	Redact []string `yaml:"redact" toml:"redact"`
+}
+
+type MemoryConfig struct {
+	// IDGenerator - генератор id: counter, uuidv7 или ulid.
+	IDGenerator string `yaml:"id_generator" toml:"id_generator"`
+}
+
+type MongoConfig struct {
+	URI        string `yaml:"uri" toml:"uri"`
+	Database   string `yaml:"database" toml:"database"`
+	Collection string `yaml:"collection" toml:"collection"`
+	// IDType - тип поля _id: objectid, uuidv7 или ulid.
+	IDType         string        `yaml:"id_type" toml:"id_type"`
+	ConnectTimeout time.Duration `yaml:"connect_timeout" toml:"connect_timeout"`
+	// ConnectAttempts - число попыток подключения при запуске; между попытками
+	// пауза растет от ConnectBackoff вдвое до ConnectBackoffMax.
+	ConnectAttempts   int           `yaml:"connect_attempts" toml:"connect_attempts"`
+	ConnectBackoff    time.Duration `yaml:"connect_backoff" toml:"connect_backoff"`
+	ConnectBackoffMax time.Duration `yaml:"connect_backoff_max" toml:"connect_backoff_max"`
+	// HeartbeatInterval - как часто драйвер проверяет серверы; он же Retry-After
+	// в ответах 503, пока база недоступна.
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval" toml:"heartbeat_interval"`
+	// BreakerFailures - сколько отказов подряд размыкает автомат защиты;
+	// он остается разомкнутым BreakerOpenTimeout, затем пропускает
+	// BreakerHalfOpenRequests пробных запросов.
+	BreakerFailures         int           `yaml:"breaker_failures" toml:"breaker_failures"`
+	BreakerOpenTimeout      time.Duration `yaml:"breaker_open_timeout" toml:"breaker_open_timeout"`
+	BreakerHalfOpenRequests int           `yaml:"breaker_half_open_requests" toml:"breaker_half_open_requests"`
+}
+
+func Default() Config {
+	return Config{
+		Addr:            ":8080",
+		Store:           "memory",
+		Timeout:         10 * time.Second,
+		ReadTimeout:     15 * time.Second,
+		WriteTimeout:    30 * time.Second,
+		IdleTimeout:     60 * time.Second,
+		ShutdownTimeout: 20 * time.Second,
+		MaxBodyBytes:    1 << 20,
+		Log: LogConfig{
+			Format:     "text",
+			Level:      "info",
+			SampleRate: 1,
+			Redact:     []string{"name", "name_prefix", "name_contains", "q", "filter", "cursor"},
+		},
+		Memory: MemoryConfig{
+			IDGenerator: "counter",
+		},
+		Mongo: MongoConfig{
+			URI:                     "mongodb://localhost:27017",
+			Database:                "lab8",
+			Collection:              "test",
+			IDType:                  "objectid",
+			ConnectTimeout:          10 * time.Second,
+			ConnectAttempts:         5,
+			ConnectBackoff:          500 * time.Millisecond,
+			ConnectBackoffMax:       10 * time.Second,
+			HeartbeatInterval:       10 * time.Second,
+			BreakerFailures:         5,
+			BreakerOpenTimeout:      15 * time.Second,
+			BreakerHalfOpenRequests: 1,
+		},
+	}
+}
+
+// Load разбирает аргументы командной строки и собирает конфигурацию.
+// Путь к файлу берется из --config или USERSVC_CONFIG.
+func Load(args []string, getenv func(string) string) (Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("usersvc", flag.ContinueOnError)
+	fc := Default()
+	configPath := fs.String("config", "", "файл конфигурации (.yaml, .yml или .toml)")
+	fs.StringVar(&fc.Addr, "addr", fc.Addr, "адрес HTTP-сервера")
+	fs.StringVar(&fc.AdminAddr, "admin-addr", fc.AdminAddr, "адрес служебного сервера с /debug/vars, пустой - выключен")
+	fs.StringVar(&fc.Store, "store", fc.Store, "хранилище пользователей: memory или mongo")
+	fs.DurationVar(&fc.Timeout, "timeout", fc.Timeout, "таймаут обработки запроса")
+	fs.Func("route-timeouts", "таймауты маршрутов, например list=5s,patch=2s", func(v string) error {
+		m, err := parseRouteTimeouts(v)
+		fc.RouteTimeouts = m
+		return err
+	})
+	fs.DurationVar(&fc.ReadTimeout, "read-timeout", fc.ReadTimeout, "таймаут чтения запроса")
+	fs.DurationVar(&fc.WriteTimeout, "write-timeout", fc.WriteTimeout, "таймаут записи ответа")
+	fs.DurationVar(&fc.IdleTimeout, "idle-timeout", fc.IdleTimeout, "таймаут простаивающего keep-alive соединения")
+	fs.DurationVar(&fc.ShutdownTimeout, "shutdown-timeout", fc.ShutdownTimeout, "время на завершение запросов при остановке")
+	fs.DurationVar(&fc.DrainDelay, "drain-delay", fc.DrainDelay, "задержка остановки, пока /readyz отвечает 503")
+	fs.BoolVar(&fc.ListEnvelope, "list-envelope", fc.ListEnvelope, "отдавать GET /users объектом с items и total вместо массива")
+	fs.StringVar(&fc.CursorSecret, "cursor-secret", fc.CursorSecret, "ключ подписи курсоров пагинации")
+	fs.Int64Var(&fc.MaxBodyBytes, "max-body-bytes", fc.MaxBodyBytes, "максимальный размер тела запроса в байтах")
+	fs.StringVar(&fc.Log.Format, "log-format", fc.Log.Format, "формат журнала: text или json")
+	fs.StringVar(&fc.Log.Level, "log-level", fc.Log.Level, "уровень журнала: debug, info, warn или error")
+	fs.Float64Var(&fc.Log.SampleRate, "log-sample-rate", fc.Log.SampleRate, "доля успешных запросов в журнале, от 0 до 1")
+	fs.Func("log-redact", "скрываемые в журнале параметры запроса через запятую", func(v string) error {
+		fc.Log.Redact = splitList(v)
+		return nil
+	})
+	fs.StringVar(&fc.Memory.IDGenerator, "id-generator", fc.Memory.IDGenerator, "генератор id для memory: counter, uuidv7 или ulid")
+	fs.StringVar(&fc.Mongo.URI, "mongo-uri", fc.Mongo.URI, "адрес MongoDB")
+	fs.StringVar(&fc.Mongo.Database, "mongo-database", fc.Mongo.Database, "имя базы данных")
+	fs.StringVar(&fc.Mongo.Collection, "mongo-collection", fc.Mongo.Collection, "имя коллекции")
+	fs.StringVar(&fc.Mongo.IDType, "mongo-id-type", fc.Mongo.IDType, "тип _id в MongoDB: objectid, uuidv7 или ulid")
+	fs.DurationVar(&fc.Mongo.ConnectTimeout, "mongo-connect-timeout", fc.Mongo.ConnectTimeout, "таймаут подключения к MongoDB")
+	fs.IntVar(&fc.Mongo.ConnectAttempts, "mongo-connect-attempts", fc.Mongo.ConnectAttempts, "число попыток подключения к MongoDB при запуске")
+	fs.DurationVar(&fc.Mongo.ConnectBackoff, "mongo-connect-backoff", fc.Mongo.ConnectBackoff, "первая пауза между попытками подключения")
+	fs.DurationVar(&fc.Mongo.ConnectBackoffMax, "mongo-connect-backoff-max", fc.Mongo.ConnectBackoffMax, "наибольшая пауза между попытками подключения")
+	fs.DurationVar(&fc.Mongo.HeartbeatInterval, "mongo-heartbeat-interval", fc.Mongo.HeartbeatInterval, "интервал проверки серверов MongoDB")
+	fs.IntVar(&fc.Mongo.BreakerFailures, "mongo-breaker-failures", fc.Mongo.BreakerFailures, "отказов MongoDB подряд до размыкания автомата защиты")
+	fs.DurationVar(&fc.Mongo.BreakerOpenTimeout, "mongo-breaker-open-timeout", fc.Mongo.BreakerOpenTimeout, "сколько автомат защиты остается разомкнутым")
+	fs.IntVar(&fc.Mongo.BreakerHalfOpenRequests, "mongo-breaker-half-open-requests", fc.Mongo.BreakerHalfOpenRequests, "пробных запросов для замыкания автомата защиты")
+	fs.BoolVar(&cfg.PrintConfig, "print-config", false, "вывести итоговую конфигурацию и выйти")
+	fs.BoolVar(&cfg.Migrate, "migrate", false, "выполнить миграции данных в MongoDB и выйти")
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	path := *configPath
+	if path == "" {
+		path = getenv(EnvPrefix + "CONFIG")
+	}
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := applyEnv(&cfg, getenv); err != nil {
+		return Config{}, err
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "addr":
+			cfg.Addr = fc.Addr
+		case "admin-addr":
+			cfg.AdminAddr = fc.AdminAddr
+		case "store":
+			cfg.Store = fc.Store
+		case "timeout":
+			cfg.Timeout = fc.Timeout
+		case "read-timeout":
+			cfg.ReadTimeout = fc.ReadTimeout
+		case "write-timeout":
+			cfg.WriteTimeout = fc.WriteTimeout
+		case "idle-timeout":
+			cfg.IdleTimeout = fc.IdleTimeout
+		case "shutdown-timeout":
+			cfg.ShutdownTimeout = fc.ShutdownTimeout
+		case "drain-delay":
+			cfg.DrainDelay = fc.DrainDelay
+		case "route-timeouts":
+			cfg.RouteTimeouts = mergeTimeouts(cfg.RouteTimeouts, fc.RouteTimeouts)
+		case "list-envelope":
+			cfg.ListEnvelope = fc.ListEnvelope
+		case "cursor-secret":
+			cfg.CursorSecret = fc.CursorSecret
+		case "max-body-bytes":
+			cfg.MaxBodyBytes = fc.MaxBodyBytes
+		case "log-format":
+			cfg.Log.Format = fc.Log.Format
+		case "log-level":
+			cfg.Log.Level = fc.Log.Level
+		case "log-sample-rate":
+			cfg.Log.SampleRate = fc.Log.SampleRate
+		case "log-redact":
+			cfg.Log.Redact = fc.Log.Redact
+		case "id-generator":
+			cfg.Memory.IDGenerator = fc.Memory.IDGenerator
+		case "mongo-uri":
+			cfg.Mongo.URI = fc.Mongo.URI
+		case "mongo-database":
+			cfg.Mongo.Database = fc.Mongo.Database
+		case "mongo-collection":
+			cfg.Mongo.Collection = fc.Mongo.Collection
+		case "mongo-id-type":
+			cfg.Mongo.IDType = fc.Mongo.IDType
+		case "mongo-connect-timeout":
+			cfg.Mongo.ConnectTimeout = fc.Mongo.ConnectTimeout
+		case "mongo-connect-attempts":
+			cfg.Mongo.ConnectAttempts = fc.Mongo.ConnectAttempts
+		case "mongo-connect-backoff":
+			cfg.Mongo.ConnectBackoff = fc.Mongo.ConnectBackoff
+		case "mongo-connect-backoff-max":
+			cfg.Mongo.ConnectBackoffMax = fc.Mongo.ConnectBackoffMax
+		case "mongo-heartbeat-interval":
+			cfg.Mongo.HeartbeatInterval = fc.Mongo.HeartbeatInterval
+		case "mongo-breaker-failures":
+			cfg.Mongo.BreakerFailures = fc.Mongo.BreakerFailures
+		case "mongo-breaker-open-timeout":
+			cfg.Mongo.BreakerOpenTimeout = fc.Mongo.BreakerOpenTimeout
+		case "mongo-breaker-half-open-requests":
+			cfg.Mongo.BreakerHalfOpenRequests = fc.Mongo.BreakerHalfOpenRequests
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("чтение конфигурации: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("разбор %s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("разбор %s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("разбор %s: неизвестный ключ %s", path, undecoded[0])
+		}
+	default:
+		return fmt.Errorf("неподдерживаемый формат конфигурации %q", path)
+	}
+	return nil
+}
+
+func applyEnv(cfg *Config, getenv func(string) string) error {
+	strs := map[string]*string{
+		"ADDR":             &cfg.Addr,
+		"ADMIN_ADDR":       &cfg.AdminAddr,
+		"STORE":            &cfg.Store,
+		"CURSOR_SECRET":    &cfg.CursorSecret,
+		"ID_GENERATOR":     &cfg.Memory.IDGenerator,
+		"MONGO_URI":        &cfg.Mongo.URI,
+		"MONGO_DATABASE":   &cfg.Mongo.Database,
+		"MONGO_COLLECTION": &cfg.Mongo.Collection,
+		"MONGO_ID_TYPE":    &cfg.Mongo.IDType,
+		"LOG_FORMAT":       &cfg.Log.Format,
+		"LOG_LEVEL":        &cfg.Log.Level,
+	}
+	for name, ptr := range strs {
+		if v := getenv(EnvPrefix + name); v != "" {
+			*ptr = v
+		}
+	}
+
+	bools := map[string]*bool{
+		"LIST_ENVELOPE": &cfg.ListEnvelope,
+	}
+	for name, ptr := range bools {
+		v := getenv(EnvPrefix + name)
+		if v == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%s%s: %w", EnvPrefix, name, err)
+		}
+		*ptr = b
+	}
+
+	ints := map[string]*int64{
+		"MAX_BODY_BYTES": &cfg.MaxBodyBytes,
+	}
+	for name, ptr := range ints {
+		v := getenv(EnvPrefix + name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s%s: %w", EnvPrefix, name, err)
+		}
+		*ptr = n
+	}
+
+	durations := map[string]*time.Duration{
+		"TIMEOUT":                    &cfg.Timeout,
+		"READ_TIMEOUT":               &cfg.ReadTimeout,
+		"WRITE_TIMEOUT":              &cfg.WriteTimeout,
+		"IDLE_TIMEOUT":               &cfg.IdleTimeout,
+		"SHUTDOWN_TIMEOUT":           &cfg.ShutdownTimeout,
+		"DRAIN_DELAY":                &cfg.DrainDelay,
+		"MONGO_CONNECT_TIMEOUT":      &cfg.Mongo.ConnectTimeout,
+		"MONGO_CONNECT_BACKOFF":      &cfg.Mongo.ConnectBackoff,
+		"MONGO_CONNECT_BACKOFF_MAX":  &cfg.Mongo.ConnectBackoffMax,
+		"MONGO_HEARTBEAT_INTERVAL":   &cfg.Mongo.HeartbeatInterval,
+		"MONGO_BREAKER_OPEN_TIMEOUT": &cfg.Mongo.BreakerOpenTimeout,
+	}
+	for name, ptr := range durations {
+		v := getenv(EnvPrefix + name)
+		if v == "" {
+			continue
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("%s%s: %w", EnvPrefix, name, err)
+		}
+		*ptr = d
+	}
+
+	counts := map[string]*int{
+		"MONGO_CONNECT_ATTEMPTS":           &cfg.Mongo.ConnectAttempts,
+		"MONGO_BREAKER_FAILURES":           &cfg.Mongo.BreakerFailures,
+		"MONGO_BREAKER_HALF_OPEN_REQUESTS": &cfg.Mongo.BreakerHalfOpenRequests,
+	}
+	for name, ptr := range counts {
+		v := getenv(EnvPrefix + name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%s%s: %w", EnvPrefix, name, err)
+		}
+		*ptr = n
+	}
+
+	if v := getenv(EnvPrefix + "LOG_SAMPLE_RATE"); v != "" {
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("%sLOG_SAMPLE_RATE: %w", EnvPrefix, err)
+		}
+		cfg.Log.SampleRate = rate
+	}
+	if v := getenv(EnvPrefix + "LOG_REDACT"); v != "" {
+		cfg.Log.Redact = splitList(v)
+	}
+
+	if v := getenv(EnvPrefix + "ROUTE_TIMEOUTS"); v != "" {
+		m, err := parseRouteTimeouts(v)
+		if err != nil {
+			return fmt.Errorf("%sROUTE_TIMEOUTS: %w", EnvPrefix, err)
+		}
+		cfg.RouteTimeouts = mergeTimeouts(cfg.RouteTimeouts, m)
+	}
+	return nil
+}
+
+// splitList разбирает список через запятую, пропуская пустые элементы.
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// Routes - имена маршрутов, допустимые в RouteTimeouts.
+var Routes = []string{"list", "get", "create", "update", "patch", "delete", "readyz"}
+
+// parseRouteTimeouts разбирает строку вида list=5s,patch=2s.
+func parseRouteTimeouts(s string) (map[string]time.Duration, error) {
+	m := map[string]time.Duration{}
+	for _, part := range strings.Split(s, ",") {
+		route, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("ожидается маршрут=таймаут, получено %q", part)
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("маршрут %s: %w", route, err)
+		}
+		m[route] = d
+	}
+	return m, nil
+}
+
+func mergeTimeouts(base, over map[string]time.Duration) map[string]time.Duration {
+	if base == nil {
+		base = map[string]time.Duration{}
+	}
+	for route, d := range over {
+		base[route] = d
+	}
+	return base
+}
+
+// Validate проверяет, что конфигурацией можно пользоваться.
+func (c Config) Validate() error {
+	var errs []error
+	if c.Addr == "" {
+		errs = append(errs, errors.New("addr не может быть пустым"))
+	}
+	if c.AdminAddr != "" && c.AdminAddr == c.Addr {
+		errs = append(errs, errors.New("admin_addr должен отличаться от addr"))
+	}
+	if c.Timeout <= 0 {
+		errs = append(errs, errors.New("timeout должен быть больше нуля"))
+	}
+	for name, d := range map[string]time.Duration{
+		"read_timeout":     c.ReadTimeout,
+		"write_timeout":    c.WriteTimeout,
+		"idle_timeout":     c.IdleTimeout,
+		"shutdown_timeout": c.ShutdownTimeout,
+	} {
+		if d <= 0 {
+			errs = append(errs, fmt.Errorf("%s должен быть больше нуля", name))
+		}
+	}
+	if c.DrainDelay < 0 {
+		errs = append(errs, errors.New("drain_delay не может быть отрицательным"))
+	}
+	// иначе соединение закроется раньше, чем обработчик успеет ответить 504
+	if c.Timeout > 0 && c.WriteTimeout > 0 && c.WriteTimeout <= c.Timeout {
+		errs = append(errs, errors.New("write_timeout должен быть больше timeout"))
+	}
+	for route, d := range c.RouteTimeouts {
+		switch {
+		case !slices.Contains(Routes, route):
+			errs = append(errs, fmt.Errorf("неизвестный маршрут %q в route_timeouts", route))
+		case d <= 0:
+			errs = append(errs, fmt.Errorf("route_timeouts.%s должен быть больше нуля", route))
+		case c.WriteTimeout > 0 && c.WriteTimeout <= d:
+			errs = append(errs, fmt.Errorf("write_timeout должен быть больше route_timeouts.%s", route))
+		}
+	}
+	if c.MaxBodyBytes <= 0 {
+		errs = append(errs, errors.New("max_body_bytes должен быть больше нуля"))
+	}
+	switch c.Log.Format {
+	case "text", "json":
+	default:
+		errs = append(errs, fmt.Errorf("неизвестный формат журнала %q", c.Log.Format))
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		errs = append(errs, fmt.Errorf("неизвестный уровень журнала %q", c.Log.Level))
+	}
+	if c.Log.SampleRate < 0 || c.Log.SampleRate > 1 {
+		errs = append(errs, errors.New("log.sample_rate должен быть от 0 до 1"))
+	}
+	switch c.Store {
+	case "memory":
+		switch c.Memory.IDGenerator {
+		case "counter", "uuidv7", "ulid":
+		default:
+			errs = append(errs, fmt.Errorf("неизвестный генератор id %q", c.Memory.IDGenerator))
+		}
+	case "mongo":
+		if c.Mongo.URI == "" {
+			errs = append(errs, errors.New("mongo.uri не может быть пустым"))
+		}
+		if c.Mongo.Database == "" {
+			errs = append(errs, errors.New("mongo.database не может быть пустым"))
+		}
+		if c.Mongo.Collection == "" {
+			errs = append(errs, errors.New("mongo.collection не может быть пустым"))
+		}
+		switch c.Mongo.IDType {
+		case "objectid", "uuidv7", "ulid":
+		default:
+			errs = append(errs, fmt.Errorf("неизвестный тип id %q", c.Mongo.IDType))
+		}
+		if c.Mongo.ConnectTimeout <= 0 {
+			errs = append(errs, errors.New("mongo.connect_timeout должен быть больше нуля"))
+		}
+		if c.Mongo.ConnectAttempts < 1 {
+			errs = append(errs, errors.New("mongo.connect_attempts должен быть не меньше 1"))
+		}
+		if c.Mongo.ConnectBackoff <= 0 {
+			errs = append(errs, errors.New("mongo.connect_backoff должен быть больше нуля"))
+		}
+		if c.Mongo.ConnectBackoffMax < c.Mongo.ConnectBackoff {
+			errs = append(errs, errors.New("mongo.connect_backoff_max должен быть не меньше mongo.connect_backoff"))
+		}
+		// драйвер не принимает интервал меньше 500 мс
+		if c.Mongo.HeartbeatInterval < 500*time.Millisecond {
+			errs = append(errs, errors.New("mongo.heartbeat_interval должен быть не меньше 500ms"))
+		}
+		if c.Mongo.BreakerFailures < 1 {
+			errs = append(errs, errors.New("mongo.breaker_failures должен быть не меньше 1"))
+		}
+		if c.Mongo.BreakerOpenTimeout <= 0 {
+			errs = append(errs, errors.New("mongo.breaker_open_timeout должен быть больше нуля"))
+		}
+		if c.Mongo.BreakerHalfOpenRequests < 1 {
+			errs = append(errs, errors.New("mongo.breaker_half_open_requests должен быть не меньше 1"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("неизвестное хранилище %q", c.Store))
+	}
+	return errors.Join(errs...)
+}
+
+// YAML возвращает конфигурацию в виде YAML, пароль в mongo.uri и cursor_secret скрыты.
+func (c Config) YAML() ([]byte, error) {
+	c.Mongo.URI = redactURI(c.Mongo.URI)
+	if c.CursorSecret != "" {
+		c.CursorSecret = "***"
+	}
+	return yaml.Marshal(c)
+}
+
+// redactURI заменяет пароль в строке подключения на "***".
+func redactURI(uri string) string {
+	scheme := strings.Index(uri, "://")
+	if scheme < 0 {
+		return uri
+	}
+	rest := uri[scheme+3:]
+	at := strings.LastIndex(rest, "@")
+	if slash := strings.Index(rest, "/"); at < 0 || (slash >= 0 && slash < at) {
+		return uri
+	}
+	colon := strings.Index(rest[:at], ":")
+	if colon < 0 {
+		return uri
+	}
+	return uri[:scheme+3] + rest[:colon+1] + "***" + rest[at:]
+}
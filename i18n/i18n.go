@@ -0,0 +1,184 @@
+// Package i18n выбирает язык по заголовку Accept-Language и отдает
+// сообщения из каталога с учетом форм множественного числа.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Form - форма множественного числа (категории CLDR).
+type Form int
+
+const (
+	Other Form = iota
+	One
+	Few
+	Many
+)
+
+// Message - текст сообщения в формате fmt. Простое сообщение задается
+// одной формой Other, сообщение с числом - всеми формами языка.
+type Message map[Form]string
+
+// Bundle - сообщения одного языка.
+type Bundle struct {
+	// Lang - основной тег языка: ru, en.
+	Lang string
+	// Plural выбирает форму для числа n.
+	Plural   func(n int) Form
+	Messages map[string]Message
+}
+
+// Catalog - набор языков с языком по умолчанию.
+type Catalog struct {
+	bundles  map[string]*Bundle
+	fallback *Bundle
+}
+
+// NewCatalog создает каталог. fallback используется, когда ни один язык
+// из Accept-Language не поддерживается или в языке нет нужного сообщения.
+func NewCatalog(fallback *Bundle, others ...*Bundle) *Catalog {
+	c := &Catalog{bundles: map[string]*Bundle{}, fallback: fallback}
+	for _, b := range append([]*Bundle{fallback}, others...) {
+		c.bundles[b.Lang] = b
+	}
+	return c
+}
+
+// Localizer - сообщения на выбранном языке.
+type Localizer struct {
+	bundle   *Bundle
+	fallback *Bundle
+}
+
+// Match выбирает язык по значению Accept-Language (RFC 9110): языки
+// перебираются по убыванию q, en-US подходит к en, q=0 исключает язык.
+func (c *Catalog) Match(acceptLanguage string) *Localizer {
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		if tag == "*" {
+			break
+		}
+		if b, ok := c.bundles[tag]; ok {
+			return &Localizer{bundle: b, fallback: c.fallback}
+		}
+	}
+	return &Localizer{bundle: c.fallback, fallback: c.fallback}
+}
+
+type weightedTag struct {
+	tag string
+	q   float64
+}
+
+// parseAcceptLanguage возвращает основные теги языков в порядке предпочтения.
+func parseAcceptLanguage(header string) []string {
+	var tags []weightedTag
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		primary, _, _ := strings.Cut(tag, "-")
+		tags = append(tags, weightedTag{primary, q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	result := make([]string, len(tags))
+	for i, t := range tags {
+		result[i] = t.tag
+	}
+	return result
+}
+
+// Lang возвращает выбранный язык для заголовка Content-Language.
+func (l *Localizer) Lang() string {
+	return l.bundle.Lang
+}
+
+// Has сообщает, есть ли сообщение key в выбранном языке или языке по умолчанию.
+func (l *Localizer) Has(key string) bool {
+	_, _, ok := l.lookup(key)
+	return ok
+}
+
+// T возвращает сообщение key, подставляя args. Если сообщения нет ни
+// в выбранном языке, ни в языке по умолчанию, возвращается сам key.
+func (l *Localizer) T(key string, args ...any) string {
+	_, msg, ok := l.lookup(key)
+	if !ok {
+		return key
+	}
+	return format(msg[Other], args)
+}
+
+// N возвращает сообщение key в форме для числа n; n подставляется первым аргументом.
+func (l *Localizer) N(key string, n int, args ...any) string {
+	b, msg, ok := l.lookup(key)
+	if !ok {
+		return key
+	}
+	// форма выбирается по правилам языка, из которого взято сообщение
+	text, ok := msg[Other]
+	if b.Plural != nil {
+		if t, found := msg[b.Plural(n)]; found {
+			text, ok = t, true
+		}
+	}
+	if !ok {
+		return key
+	}
+	return format(text, append([]any{n}, args...))
+}
+
+func (l *Localizer) lookup(key string) (*Bundle, Message, bool) {
+	if msg, ok := l.bundle.Messages[key]; ok {
+		return l.bundle, msg, true
+	}
+	msg, ok := l.fallback.Messages[key]
+	return l.fallback, msg, ok
+}
+
+func format(text string, args []any) string {
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// PluralRu - правила русского языка: 1 символ, 2 символа, 5 символов, 21 символ.
+func PluralRu(n int) Form {
+	if n < 0 {
+		n = -n
+	}
+	switch mod10, mod100 := n%10, n%100; {
+	case mod10 == 1 && mod100 != 11:
+		return One
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return Few
+	default:
+		return Many
+	}
+}
+
+// PluralEn - правила английского языка: 1 character, 2 characters.
+func PluralEn(n int) Form {
+	if n == 1 {
+		return One
+	}
+	return Other
+}
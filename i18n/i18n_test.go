@@ -0,0 +1,82 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testRu = &Bundle{
+		Lang:   "ru",
+		Plural: PluralRu,
+		Messages: map[string]Message{
+			"hello": {Other: "Привет, %s"},
+			"users": {One: "%d пользователь", Few: "%d пользователя", Many: "%d пользователей"},
+			"only":  {Other: "только по-русски"},
+			"days":  {One: "%d день", Few: "%d дня", Many: "%d дней"},
+		},
+	}
+	testEn = &Bundle{
+		Lang:   "en",
+		Plural: PluralEn,
+		Messages: map[string]Message{
+			"hello": {Other: "Hello, %s"},
+			"users": {One: "%d user", Other: "%d users"},
+		},
+	}
+)
+
+// Тестирование выбора языка по Accept-Language
+func TestMatch(t *testing.T) {
+	c := NewCatalog(testRu, testEn)
+
+	tests := []struct {
+		header string
+		lang   string
+	}{
+		{"", "ru"},
+		{"en", "en"},
+		{"en-US,en;q=0.9", "en"},
+		{"de-DE, en;q=0.5, ru;q=0.8", "ru"},
+		{"fr, de", "ru"},
+		{"en;q=0, ru", "ru"},
+		{"*", "ru"},
+		{"EN-gb", "en"},
+		{"en;q=abc, ru;q=0.1", "ru"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.lang, c.Match(tt.header).Lang(), tt.header)
+	}
+}
+
+// Тестирование подстановки и запасного языка
+func TestT(t *testing.T) {
+	c := NewCatalog(testRu, testEn)
+	en := c.Match("en")
+
+	assert.Equal(t, "Hello, Alice", en.T("hello", "Alice"))
+	assert.Equal(t, "Привет, Alice", c.Match("ru").T("hello", "Alice"))
+	assert.Equal(t, "только по-русски", en.T("only"))
+	assert.Equal(t, "missing.key", en.T("missing.key"))
+}
+
+// Тестирование форм множественного числа
+func TestN(t *testing.T) {
+	c := NewCatalog(testRu, testEn)
+	ru, en := c.Match("ru"), c.Match("en")
+
+	for n, want := range map[int]string{
+		1: "1 пользователь", 2: "2 пользователя", 5: "5 пользователей",
+		11: "11 пользователей", 12: "12 пользователей", 21: "21 пользователь",
+		22: "22 пользователя", 100: "100 пользователей", 111: "111 пользователей",
+	} {
+		assert.Equal(t, want, ru.N("users", n))
+	}
+	assert.Equal(t, "1 user", en.N("users", 1))
+	assert.Equal(t, "0 users", en.N("users", 0))
+	assert.Equal(t, "3 users", en.N("users", 3))
+	// сообщения нет в английском - форма выбирается по правилам русского
+	assert.Equal(t, "5 дней", en.N("days", 5))
+	assert.Equal(t, "2 дня", en.N("days", 2))
+}
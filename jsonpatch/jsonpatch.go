@@ -0,0 +1,424 @@
+// Package jsonpatch применяет к JSON-документам JSON Merge Patch (RFC 7396)
+// и JSON Patch (RFC 6902).
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalid - патч не разбирается или содержит неизвестную операцию.
+	ErrInvalid = errors.New("неверный патч")
+	// ErrTestFailed - операция test не прошла.
+	ErrTestFailed = errors.New("проверка test не прошла")
+	// ErrPath - путь патча не подходит к документу.
+	ErrPath = errors.New("неверный путь")
+)
+
+// Коды причин ошибок. Error() возвращает текст на русском; сервис может
+// перевести сообщение сам по коду и Args.
+const (
+	// CodeInvalidJSON - патч не разбирается, Args: ошибка encoding/json.
+	CodeInvalidJSON = "invalid_json"
+	// CodeTrailingData - лишние данные после JSON.
+	CodeTrailingData = "trailing_data"
+	// CodeNoValue - у операции нет value.
+	CodeNoValue = "no_value"
+	// CodeUnknownOp - неизвестная операция, Args: op.
+	CodeUnknownOp = "unknown_op"
+	// CodeBadPointer - путь не начинается с /, Args: путь.
+	CodeBadPointer = "bad_pointer"
+	// CodeMoveIntoSelf - move внутрь перемещаемого значения.
+	CodeMoveIntoSelf = "move_into_self"
+	// CodeNoField - нет поля, Args: имя.
+	CodeNoField = "no_field"
+	// CodeScalar - путь продолжается внутри скалярного значения, Args: ключ.
+	CodeScalar = "scalar"
+	// CodeBadIndex - неверный индекс массива, Args: индекс.
+	CodeBadIndex = "bad_index"
+	// CodeTestFailed - значение не совпало в операции test.
+	CodeTestFailed = "test_failed"
+)
+
+var texts = map[string]string{
+	CodeInvalidJSON:  "%s",
+	CodeTrailingData: "лишние данные после JSON",
+	CodeNoValue:      "нет value",
+	CodeUnknownOp:    "неизвестная операция %q",
+	CodeBadPointer:   "%q должен начинаться с /",
+	CodeMoveIntoSelf: "нельзя переместить значение внутрь самого себя",
+	CodeNoField:      "нет поля %q",
+	CodeScalar:       "%q внутри скалярного значения",
+	CodeBadIndex:     "неверный индекс %q",
+	CodeTestFailed:   "значение не совпадает",
+}
+
+// Error - причина ошибки патча. errors.Is(err, Kind) == true.
+type Error struct {
+	// Kind - ErrInvalid, ErrPath или ErrTestFailed.
+	Kind error
+	Code string
+	Args []any
+}
+
+func (e *Error) Error() string {
+	return e.Kind.Error() + ": " + fmt.Sprintf(texts[e.Code], e.Args...)
+}
+
+func (e *Error) Is(target error) bool { return target == e.Kind }
+
+// OpError - ошибка операции Index патча JSON Patch.
+type OpError struct {
+	Index    int
+	Op, Path string
+	Err      error
+}
+
+func (e *OpError) Error() string {
+	return fmt.Sprintf("операция %d (%s %s): %v", e.Index, e.Op, e.Path, e.Err)
+}
+
+func (e *OpError) Unwrap() error { return e.Err }
+
+func decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data[dec.InputOffset():])) > 0 {
+		return nil, &Error{Kind: ErrInvalid, Code: CodeTrailingData}
+	}
+	return v, nil
+}
+
+// MergePatch применяет JSON Merge Patch: объекты сливаются рекурсивно,
+// null удаляет поле, остальные значения заменяют прежние целиком.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	p, err := decode(patch)
+	if err != nil {
+		return nil, invalidJSON(err)
+	}
+	return json.Marshal(merge(target, p))
+}
+
+// invalidJSON переводит ошибку разбора патча в *Error с ErrInvalid.
+func invalidJSON(err error) error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return &Error{Kind: ErrInvalid, Code: CodeInvalidJSON, Args: []any{err.Error()}}
+}
+
+func merge(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = merge(t[k], v)
+	}
+	return t
+}
+
+// Operation - операция JSON Patch.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+
+	// hasValue - в операции было поле value; "value": null - тоже значение.
+	hasValue bool
+}
+
+func (op *Operation) UnmarshalJSON(data []byte) error {
+	type operation Operation
+	if err := json.Unmarshal(data, (*operation)(op)); err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	op.Value, op.hasValue = fields["value"]
+	return nil
+}
+
+// Apply применяет JSON Patch. Операции выполняются по порядку; если хотя бы одна
+// не удалась, возвращается ошибка и документ считается неизменным.
+func Apply(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []Operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, invalidJSON(err)
+	}
+
+	for i, op := range ops {
+		target, err = op.apply(target)
+		if err != nil {
+			return nil, &OpError{Index: i, Op: op.Op, Path: op.Path, Err: err}
+		}
+	}
+	return json.Marshal(target)
+}
+
+func (op Operation) value() (any, error) {
+	if !op.hasValue {
+		return nil, &Error{Kind: ErrInvalid, Code: CodeNoValue}
+	}
+	return decode(op.Value)
+}
+
+func (op Operation) apply(doc any) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add":
+		v, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, v)
+	case "remove":
+		doc, _, err := remove(doc, path)
+		return doc, err
+	case "replace":
+		v, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		doc, _, err := remove(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, v)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		var v any
+		if op.Op == "move" {
+			if isPrefix(from, path) && len(from) < len(path) {
+				return nil, &Error{Kind: ErrPath, Code: CodeMoveIntoSelf}
+			}
+			doc, v, err = remove(doc, from)
+		} else {
+			v, err = get(doc, from)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, v)
+	case "test":
+		want, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		got, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(got, want) {
+			return nil, &Error{Kind: ErrTestFailed, Code: CodeTestFailed}
+		}
+		return doc, nil
+	}
+	return nil, &Error{Kind: ErrInvalid, Code: CodeUnknownOp, Args: []any{op.Op}}
+}
+
+// parsePointer разбирает JSON Pointer (RFC 6901).
+func parsePointer(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(s, "/") {
+		return nil, &Error{Kind: ErrInvalid, Code: CodeBadPointer, Args: []any{s}}
+	}
+	parts := strings.Split(s[1:], "/")
+	for i, p := range parts {
+		parts[i] = strings.ReplaceAll(strings.ReplaceAll(p, "~1", "/"), "~0", "~")
+	}
+	return parts, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	return len(prefix) <= len(path) && reflect.DeepEqual(prefix, path[:len(prefix)])
+}
+
+func get(doc any, path []string) (any, error) {
+	for _, key := range path {
+		switch d := doc.(type) {
+		case map[string]any:
+			v, ok := d[key]
+			if !ok {
+				return nil, &Error{Kind: ErrPath, Code: CodeNoField, Args: []any{key}}
+			}
+			doc = v
+		case []any:
+			i, err := index(key, len(d)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = d[i]
+		default:
+			return nil, &Error{Kind: ErrPath, Code: CodeScalar, Args: []any{key}}
+		}
+	}
+	return doc, nil
+}
+
+func index(key string, max int) (int, error) {
+	i, err := strconv.Atoi(key)
+	if err != nil || i < 0 || i > max || (len(key) > 1 && key[0] == '0') {
+		return 0, &Error{Kind: ErrPath, Code: CodeBadIndex, Args: []any{key}}
+	}
+	return i, nil
+}
+
+// add вставляет v по пути и возвращает новый корень документа.
+func add(doc any, path []string, v any) (any, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	key := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		p[key] = v
+		return doc, nil
+	case []any:
+		i := len(p)
+		if key != "-" {
+			if i, err = index(key, len(p)); err != nil {
+				return nil, err
+			}
+		}
+		p = append(p[:i], append([]any{v}, p[i:]...)...)
+		return set(doc, path[:len(path)-1], p)
+	}
+	return nil, &Error{Kind: ErrPath, Code: CodeScalar, Args: []any{key}}
+}
+
+// set заменяет существующее значение по пути; нужен, чтобы записать
+// измененный срез массива обратно в родителя.
+func set(doc any, path []string, v any) (any, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	key := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		p[key] = v
+	case []any:
+		i, err := index(key, len(p)-1)
+		if err != nil {
+			return nil, err
+		}
+		p[i] = v
+	}
+	return doc, nil
+}
+
+// remove удаляет значение по пути и возвращает новый корень и удаленное значение.
+func remove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	key := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		v, ok := p[key]
+		if !ok {
+			return nil, nil, &Error{Kind: ErrPath, Code: CodeNoField, Args: []any{key}}
+		}
+		delete(p, key)
+		return doc, v, nil
+	case []any:
+		i, err := index(key, len(p)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		v := p[i]
+		p = append(p[:i:i], p[i+1:]...)
+		doc, err := set(doc, path[:len(path)-1], p)
+		return doc, v, err
+	}
+	return nil, nil, &Error{Kind: ErrPath, Code: CodeScalar, Args: []any{key}}
+}
+
+// equal сравнивает JSON-значения; числа сравниваются по значению, а не по записи.
+func equal(a, b any) bool {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, errA := strconv.ParseFloat(a.String(), 64)
+		y, errB := strconv.ParseFloat(b.String(), 64)
+		return errA == nil && errB == nil && x == y
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, v := range a {
+			if w, ok := b[k]; !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
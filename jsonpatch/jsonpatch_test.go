@@ -0,0 +1,98 @@
+package jsonpatch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePatch(t *testing.T) {
+	cases := []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+	}
+	for _, c := range cases {
+		got, err := MergePatch([]byte(c.doc), []byte(c.patch))
+		assert.NoError(t, err, c.patch)
+		assert.JSONEq(t, c.want, string(got), c.patch)
+	}
+
+	_, err := MergePatch([]byte(`{}`), []byte(`{`))
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestApply(t *testing.T) {
+	cases := []struct{ doc, patch, want string }{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"foo":"bar","baz":"qux"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":"qux"}]`, `{"foo":["bar","qux"]}`},
+		{`{"a":[[1,2],[3]]}`, `[{"op":"add","path":"/a/0/1","value":9}]`, `{"a":[[1,9,2],[3]]}`},
+		{`{"a":[[1,2],[3]]}`, `[{"op":"remove","path":"/a/0/0"}]`, `{"a":[[2],[3]]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"baz":"qux"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo"}`},
+		{`{"foo":{"bar":"baz"}}`, `[{"op":"move","from":"/foo/bar","path":"/qux"}]`, `{"foo":{},"qux":"baz"}`},
+		{`{"foo":["a","b","c"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/2"}]`, `{"foo":["a","c","b"]}`},
+		{`{"a":1}`, `[{"op":"copy","from":"/a","path":"/b"}]`, `{"a":1,"b":1}`},
+		{`{"a/b":1,"m~n":2}`, `[{"op":"test","path":"/a~1b","value":1.0},{"op":"remove","path":"/m~0n"}]`, `{"a/b":1}`},
+		{`{"a":"b"}`, `[{"op":"replace","path":"/a","value":null}]`, `{"a":null}`},
+		{`{"a":null}`, `[{"op":"test","path":"/a","value":null},{"op":"add","path":"/b","value":null}]`, `{"a":null,"b":null}`},
+	}
+	for _, c := range cases {
+		got, err := Apply([]byte(c.doc), []byte(c.patch))
+		assert.NoError(t, err, c.patch)
+		assert.JSONEq(t, c.want, string(got), c.patch)
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	cases := []struct {
+		patch string
+		want  error
+	}{
+		{`{"op":"add"}`, ErrInvalid},
+		{`[{"op":"frobnicate","path":"/a"}]`, ErrInvalid},
+		{`[{"op":"add","path":"/b"}]`, ErrInvalid},
+		{`[{"op":"replace","path":"/a"}]`, ErrInvalid},
+		{`[{"op":"test","path":"/a","value":null}]`, ErrTestFailed},
+		{`[{"op":"add","path":"a","value":1}]`, ErrInvalid},
+		{`[{"op":"remove","path":"/missing"}]`, ErrPath},
+		{`[{"op":"add","path":"/list/5","value":1}]`, ErrPath},
+		{`[{"op":"add","path":"/list/01","value":1}]`, ErrPath},
+		{`[{"op":"move","from":"/obj","path":"/obj/x"}]`, ErrPath},
+		{`[{"op":"test","path":"/a","value":2}]`, ErrTestFailed},
+		{`[{"op":"replace","path":"/a","value":5},{"op":"test","path":"/a","value":1}]`, ErrTestFailed},
+	}
+	for _, c := range cases {
+		_, err := Apply([]byte(`{"a":1,"list":[1],"obj":{}}`), []byte(c.patch))
+		assert.True(t, errors.Is(err, c.want), "%s: %v", c.patch, err)
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	_, err := Apply([]byte(`{"a":1}`), []byte(`[{"op":"test","path":"/a","value":1},{"op":"remove","path":"/b"}]`))
+	var opErr *OpError
+	require.ErrorAs(t, err, &opErr)
+	assert.Equal(t, 1, opErr.Index)
+	assert.Equal(t, "remove", opErr.Op)
+	var patchErr *Error
+	require.ErrorAs(t, err, &patchErr)
+	assert.Equal(t, CodeNoField, patchErr.Code)
+	assert.Equal(t, []any{"b"}, patchErr.Args)
+	assert.EqualError(t, err, `операция 1 (remove /b): неверный путь: нет поля "b"`)
+
+	for _, patch := range []string{`{} {}`, `{"name":"X"} }`, `{"name":"X"}]`} {
+		_, err = MergePatch([]byte(`{}`), []byte(patch))
+		require.ErrorAs(t, err, &patchErr, patch)
+		assert.ErrorIs(t, err, ErrInvalid, patch)
+		assert.Equal(t, CodeTrailingData, patchErr.Code, patch)
+	}
+	_, err = MergePatch([]byte(`{}`), []byte("{\"name\":\"X\"} \n"))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,87 @@
+// Package requestid хранит идентификатор запроса в контексте и добавляет
+// его к записям slog, чтобы ответ клиенту, журнал сервиса и операции
+// в MongoDB можно было сопоставить.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+)
+
+// Header - заголовок запроса и ответа с идентификатором.
+const Header = "X-Request-ID"
+
+// maxLen - максимальная длина идентификатора, принятого от клиента.
+const maxLen = 128
+
+// New создает случайный идентификатор из 32 hex-символов.
+func New() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Valid сообщает, можно ли принять идентификатор от клиента: непустой,
+// не длиннее 128 символов, только латиница, цифры и -_.:
+// Иначе клиент мог бы подделать строки журнала.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLen {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+type contextKey struct{}
+
+// NewContext возвращает контекст с идентификатором запроса.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext возвращает идентификатор запроса или "", если его нет.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Attr - имя поля с идентификатором в записях журнала.
+const Attr = "request_id"
+
+// Handler добавляет к записям поле request_id, если в контексте записи
+// есть идентификатор запроса.
+type Handler struct {
+	slog.Handler
+}
+
+// NewHandler оборачивает h. Уже обернутый обработчик возвращается как есть.
+func NewHandler(h slog.Handler) slog.Handler {
+	if _, ok := h.(Handler); ok {
+		return h
+	}
+	return Handler{h}
+}
+
+func (h Handler) Handle(ctx context.Context, r slog.Record) error {
+	if id := FromContext(ctx); id != "" {
+		r.AddAttrs(slog.String(Attr, id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return Handler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h Handler) WithGroup(name string) slog.Handler {
+	return Handler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,49 @@
+package requestid
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тестирование проверки идентификатора от клиента
+func TestValid(t *testing.T) {
+	assert.True(t, Valid(New()))
+	assert.Len(t, New(), 32)
+	assert.NotEqual(t, New(), New())
+
+	assert.True(t, Valid("req-1"))
+	assert.True(t, Valid("01HZX3.web_1:42"))
+	assert.False(t, Valid(""))
+	assert.False(t, Valid(strings.Repeat("a", 129)))
+	assert.False(t, Valid("a b"))
+	assert.False(t, Valid("id\nlevel=ERROR"))
+	assert.False(t, Valid("запрос"))
+}
+
+// Тестирование поля request_id в записях журнала
+func TestHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(slog.NewJSONHandler(&buf, nil))
+	assert.Equal(t, h, NewHandler(h))
+	logger := slog.New(h).With("component", "test")
+
+	logger.InfoContext(NewContext(context.Background(), "req-1"), "с идентификатором")
+	logger.InfoContext(context.Background(), "без идентификатора")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var first, second map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, "req-1", first["request_id"])
+	assert.Equal(t, "test", first["component"])
+	assert.NotContains(t, second, "request_id")
+}
@@ -0,0 +1,64 @@
+// Package rsql разбирает фильтры в стиле RSQL/FIQL, например
+// "age>=18;name=like=Ив*,name==Bob", в дерево условий и переводит его
+// в запрос Mongo (ToBSON) или в проверку значений в памяти (Match).
+//
+// ";" - И, "," - ИЛИ (И связывает сильнее), скобки группируют условия.
+// Операторы: ==, !=, <, =lt=, <=, =le=, >, =gt=, >=, =ge=, =in=, =out=, =like=.
+// В =like= символ * означает любую последовательность символов, регистр не учитывается.
+package rsql
+
+import "regexp"
+
+// Node - узел дерева фильтра: *Logical или *Comparison.
+type Node interface {
+	node()
+}
+
+// Logical объединяет условия через И (And) или ИЛИ (Or).
+type Logical struct {
+	Op    LogicalOp
+	Nodes []Node
+}
+
+type LogicalOp string
+
+const (
+	And LogicalOp = ";"
+	Or  LogicalOp = ","
+)
+
+// Comparison - условие "поле оператор значения".
+// Args - значения как в запросе, Values заполняет Bind по типу поля.
+type Comparison struct {
+	Field  string
+	Op     Operator
+	Args   []string
+	Values []any
+
+	like *regexp.Regexp
+}
+
+type Operator string
+
+const (
+	Equal          Operator = "=="
+	NotEqual       Operator = "!="
+	Less           Operator = "=lt="
+	LessOrEqual    Operator = "=le="
+	Greater        Operator = "=gt="
+	GreaterOrEqual Operator = "=ge="
+	In             Operator = "=in="
+	NotIn          Operator = "=out="
+	Like           Operator = "=like="
+)
+
+// aliases - короткие записи операторов сравнения.
+var aliases = map[string]Operator{
+	"<":  Less,
+	"<=": LessOrEqual,
+	">":  Greater,
+	">=": GreaterOrEqual,
+}
+
+func (*Logical) node()    {}
+func (*Comparison) node() {}
@@ -0,0 +1,129 @@
+package rsql
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ToBSON переводит дерево, прошедшее Bind, в запрос Mongo.
+// field переводит имя поля фильтра в имя поля документа.
+func ToBSON(n Node, field func(string) string) bson.M {
+	switch n := n.(type) {
+	case *Logical:
+		parts := make(bson.A, len(n.Nodes))
+		for i, child := range n.Nodes {
+			parts[i] = ToBSON(child, field)
+		}
+		if n.Op == And {
+			return bson.M{"$and": parts}
+		}
+		return bson.M{"$or": parts}
+	case *Comparison:
+		var cond any
+		switch n.Op {
+		case Equal:
+			cond = n.Values[0]
+		case NotEqual:
+			cond = bson.M{"$ne": n.Values[0]}
+		case Less:
+			cond = bson.M{"$lt": n.Values[0]}
+		case LessOrEqual:
+			cond = bson.M{"$lte": n.Values[0]}
+		case Greater:
+			cond = bson.M{"$gt": n.Values[0]}
+		case GreaterOrEqual:
+			cond = bson.M{"$gte": n.Values[0]}
+		case In:
+			cond = bson.M{"$in": n.Values}
+		case NotIn:
+			cond = bson.M{"$nin": n.Values}
+		case Like:
+			cond = bson.M{"$regex": likePattern(n.Args[0]), "$options": "i"}
+		}
+		return bson.M{field(n.Field): cond}
+	}
+	panic(fmt.Sprintf("rsql: неизвестный узел %T", n))
+}
+
+// likePattern переводит шаблон =like= в регулярное выражение:
+// * заменяется на .*, остальное экранируется.
+func likePattern(arg string) string {
+	parts := strings.Split(arg, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return "^" + strings.Join(parts, ".*") + "$"
+}
+
+// Match проверяет значения по дереву, прошедшему Bind.
+// get возвращает значение поля: string, целое число или time.Time.
+func Match(n Node, get func(field string) any) bool {
+	switch n := n.(type) {
+	case *Logical:
+		if n.Op == And {
+			for _, child := range n.Nodes {
+				if !Match(child, get) {
+					return false
+				}
+			}
+			return true
+		}
+		for _, child := range n.Nodes {
+			if Match(child, get) {
+				return true
+			}
+		}
+		return false
+	case *Comparison:
+		v := get(n.Field)
+		switch n.Op {
+		case Equal:
+			return compare(v, n.Values[0]) == 0
+		case NotEqual:
+			return compare(v, n.Values[0]) != 0
+		case Less:
+			return compare(v, n.Values[0]) < 0
+		case LessOrEqual:
+			return compare(v, n.Values[0]) <= 0
+		case Greater:
+			return compare(v, n.Values[0]) > 0
+		case GreaterOrEqual:
+			return compare(v, n.Values[0]) >= 0
+		case In, NotIn:
+			found := slices.ContainsFunc(n.Values, func(x any) bool { return compare(v, x) == 0 })
+			return found == (n.Op == In)
+		case Like:
+			s, _ := v.(string)
+			return n.like.MatchString(s)
+		}
+	}
+	panic(fmt.Sprintf("rsql: неизвестный узел %T", n))
+}
+
+// compare сравнивает значение поля со значением из фильтра того же типа.
+func compare(v, x any) int {
+	switch x := x.(type) {
+	case string:
+		s, _ := v.(string)
+		return cmp.Compare(s, x)
+	case int64:
+		var n int64
+		switch v := v.(type) {
+		case int:
+			n = int64(v)
+		case int64:
+			n = v
+		}
+		return cmp.Compare(n, x)
+	case time.Time:
+		t, _ := v.(time.Time)
+		return t.Compare(x)
+	}
+	panic(fmt.Sprintf("rsql: сравнение со значением типа %T", x))
+}
@@ -0,0 +1,72 @@
+package rsql
+
+import "fmt"
+
+// Коды ошибок фильтра. Error() возвращает текст на русском; сервис может
+// перевести сообщение сам по коду и Args.
+const (
+	// CodeUnexpectedChar - лишний символ, Args: символ.
+	CodeUnexpectedChar = "unexpected_char"
+	// CodeExpectedParen - нет закрывающей скобки.
+	CodeExpectedParen = "expected_paren"
+	// CodeExpectedField - нет имени поля.
+	CodeExpectedField = "expected_field"
+	// CodeExpectedOperator - нет оператора сравнения.
+	CodeExpectedOperator = "expected_operator"
+	// CodeExpectedValue - нет значения.
+	CodeExpectedValue = "expected_value"
+	// CodeUnclosedQuote - незакрытая кавычка.
+	CodeUnclosedQuote = "unclosed_quote"
+	// CodeSingleValue - оператор принимает одно значение, Args: оператор.
+	CodeSingleValue = "single_value"
+	// CodeUnknownField - поля нет в схеме, Args: поле.
+	CodeUnknownField = "unknown_field"
+	// CodeLikeNotString - =like= к нестроковому полю, Args: поле.
+	CodeLikeNotString = "like_not_string"
+	// CodeLikeWildcards - слишком много * в шаблоне, Args: поле, MaxLikeWildcards.
+	CodeLikeWildcards = "like_wildcards"
+	// CodeNotInt - значение не целое число, Args: поле, значение.
+	CodeNotInt = "not_int"
+	// CodeNotTime - значение не время RFC 3339, Args: поле, значение.
+	CodeNotTime = "not_time"
+	// CodeInvalidValue - значение не в кодировке UTF-8 или шаблон не
+	// собирается в регулярное выражение, Args: поле.
+	CodeInvalidValue = "invalid_value"
+)
+
+var texts = map[string]string{
+	CodeUnexpectedChar:   "лишний символ %q",
+	CodeExpectedParen:    "ожидается )",
+	CodeExpectedField:    "ожидается имя поля",
+	CodeExpectedOperator: "ожидается оператор сравнения",
+	CodeExpectedValue:    "ожидается значение",
+	CodeUnclosedQuote:    "незакрытая кавычка",
+	CodeSingleValue:      "оператор %s принимает одно значение",
+	CodeUnknownField:     "поле %q недоступно для фильтрации",
+	CodeLikeNotString:    "оператор =like= применим только к строковым полям, а %q не строка",
+	CodeLikeWildcards:    "поле %q: шаблон =like= может содержать не больше %d символов *",
+	CodeNotInt:           "поле %q: %q не целое число",
+	CodeNotTime:          "поле %q: %q не время в формате RFC 3339",
+	CodeInvalidValue:     "поле %q: недопустимое значение",
+}
+
+// Error - ошибка фильтра, найденная Bind.
+type Error struct {
+	Code string
+	Args []any
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf(texts[e.Code], e.Args...)
+}
+
+// SyntaxError - ошибка разбора с позицией (в байтах) в исходной строке.
+type SyntaxError struct {
+	Pos  int
+	Code string
+	Args []any
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("позиция %d: %s", e.Pos, fmt.Sprintf(texts[e.Code], e.Args...))
+}
@@ -0,0 +1,194 @@
+package rsql
+
+import "strings"
+
+// reserved - символы, которые нельзя использовать в значениях без кавычек.
+const reserved = "\"'();,=!~<> "
+
+// Parse разбирает строку фильтра в дерево.
+func Parse(input string) (Node, error) {
+	p := &parser{input: input}
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.input) {
+		return nil, p.errorf(CodeUnexpectedChar, p.input[p.pos])
+	}
+	return n, nil
+}
+
+type parser struct {
+	input string
+	pos   int
+}
+
+func (p *parser) errorf(code string, args ...any) error {
+	return &SyntaxError{Pos: p.pos, Code: code, Args: args}
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) or() (Node, error) {
+	return p.logical(Or, p.and)
+}
+
+func (p *parser) and() (Node, error) {
+	return p.logical(And, p.constraint)
+}
+
+// logical разбирает последовательность next, разделенную op.
+// Единственное условие возвращается без обертки.
+func (p *parser) logical(op LogicalOp, next func() (Node, error)) (Node, error) {
+	first, err := next()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []Node{first}
+	for p.peek() == op[0] {
+		p.pos++
+		n, err := next()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	if len(nodes) == 1 {
+		return first, nil
+	}
+	return &Logical{Op: op, Nodes: nodes}, nil
+}
+
+func (p *parser) constraint() (Node, error) {
+	if p.peek() != '(' {
+		return p.comparison()
+	}
+	p.pos++
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != ')' {
+		return nil, p.errorf(CodeExpectedParen)
+	}
+	p.pos++
+	return n, nil
+}
+
+func (p *parser) comparison() (Node, error) {
+	start := p.pos
+	for p.pos < len(p.input) && isSelectorChar(p.input[p.pos]) {
+		p.pos++
+	}
+	if start == p.pos {
+		return nil, p.errorf(CodeExpectedField)
+	}
+	c := &Comparison{Field: p.input[start:p.pos]}
+
+	op, err := p.operator()
+	if err != nil {
+		return nil, err
+	}
+	c.Op = op
+
+	if p.peek() == '(' {
+		p.pos++
+		for {
+			arg, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			c.Args = append(c.Args, arg)
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf(CodeExpectedParen)
+		}
+		p.pos++
+	} else {
+		arg, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		c.Args = []string{arg}
+	}
+
+	switch {
+	case c.Op == In || c.Op == NotIn:
+	case len(c.Args) != 1:
+		return nil, &SyntaxError{Pos: start, Code: CodeSingleValue, Args: []any{c.Op}}
+	}
+	return c, nil
+}
+
+func (p *parser) operator() (Operator, error) {
+	rest := p.input[p.pos:]
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if strings.HasPrefix(rest, op) {
+			p.pos += len(op)
+			if alias, ok := aliases[op]; ok {
+				return alias, nil
+			}
+			return Operator(op), nil
+		}
+	}
+	if strings.HasPrefix(rest, "=") {
+		end := strings.IndexByte(rest[1:], '=')
+		if end > 0 {
+			op := Operator(rest[:end+2])
+			switch op {
+			case Less, LessOrEqual, Greater, GreaterOrEqual, In, NotIn, Like:
+				p.pos += len(op)
+				return op, nil
+			}
+		}
+	}
+	return "", p.errorf(CodeExpectedOperator)
+}
+
+// value разбирает значение: без кавычек или в одинарных/двойных кавычках
+// с экранированием через обратную косую черту.
+func (p *parser) value() (string, error) {
+	quote := p.peek()
+	if quote != '"' && quote != '\'' {
+		start := p.pos
+		for p.pos < len(p.input) && !strings.ContainsRune(reserved, rune(p.input[p.pos])) {
+			p.pos++
+		}
+		if start == p.pos {
+			return "", p.errorf(CodeExpectedValue)
+		}
+		return p.input[start:p.pos], nil
+	}
+
+	start := p.pos
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.input):
+			b.WriteByte(p.input[p.pos+1])
+			p.pos += 2
+		case c == quote:
+			p.pos++
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", &SyntaxError{Pos: start, Code: CodeUnclosedQuote}
+}
+
+func isSelectorChar(c byte) bool {
+	return c == '_' || c == '.' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
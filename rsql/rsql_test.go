@@ -0,0 +1,131 @@
+package rsql
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var schema = Schema{"name": String, "age": Int, "created_at": Time}
+
+func bind(t *testing.T, input string) Node {
+	n, err := Parse(input)
+	require.NoError(t, err, input)
+	require.NoError(t, Bind(n, schema), input)
+	return n
+}
+
+func TestParse(t *testing.T) {
+	n, err := Parse("age>=18;name=like=Ив*,name==Bob")
+	require.NoError(t, err)
+	assert.Equal(t, &Logical{Op: Or, Nodes: []Node{
+		&Logical{Op: And, Nodes: []Node{
+			&Comparison{Field: "age", Op: GreaterOrEqual, Args: []string{"18"}},
+			&Comparison{Field: "name", Op: Like, Args: []string{"Ив*"}},
+		}},
+		&Comparison{Field: "name", Op: Equal, Args: []string{"Bob"}},
+	}}, n)
+
+	n, err = Parse(`name=in=("Анна Петрова",'O\'Brien');(age=lt=10,age=gt=60)`)
+	require.NoError(t, err)
+	assert.Equal(t, &Logical{Op: And, Nodes: []Node{
+		&Comparison{Field: "name", Op: In, Args: []string{"Анна Петрова", "O'Brien"}},
+		&Logical{Op: Or, Nodes: []Node{
+			&Comparison{Field: "age", Op: Less, Args: []string{"10"}},
+			&Comparison{Field: "age", Op: Greater, Args: []string{"60"}},
+		}},
+	}}, n)
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := map[string]int{
+		"":                 0,
+		"age":              3,
+		"age=foo=1":        3,
+		"age==":            5,
+		"age==1;":          7,
+		"(age==1":          7,
+		"age==1)":          6,
+		"name=='Bob":       6,
+		"age==(1,2)":       0,
+		"name==Bob;;age>1": 10,
+	}
+	for input, pos := range cases {
+		_, err := Parse(input)
+		var syntaxErr *SyntaxError
+		if assert.True(t, errors.As(err, &syntaxErr), input) {
+			assert.Equal(t, pos, syntaxErr.Pos, input)
+		}
+	}
+
+	_, err := Parse("name=='Bob")
+	assert.EqualError(t, err, "позиция 6: незакрытая кавычка")
+}
+
+func TestBindErrors(t *testing.T) {
+	cases := map[string]*Error{
+		"password==1":          {Code: CodeUnknownField, Args: []any{"password"}},
+		"age==old":             {Code: CodeNotInt, Args: []any{"age", "old"}},
+		"age=like=1*":          {Code: CodeLikeNotString, Args: []any{"age"}},
+		"created_at>yesterday": {Code: CodeNotTime, Args: []any{"created_at", "yesterday"}},
+		"name=like=*a*a*a*a*b": {Code: CodeLikeWildcards, Args: []any{"name", MaxLikeWildcards}},
+		"name=like=\x98":       {Code: CodeInvalidValue, Args: []any{"name"}},
+		"name==\xff":           {Code: CodeInvalidValue, Args: []any{"name"}},
+	}
+	for input, want := range cases {
+		n, err := Parse(input)
+		require.NoError(t, err, input)
+		err = Bind(n, schema)
+		var bindErr *Error
+		if assert.True(t, errors.As(err, &bindErr), input) {
+			assert.Equal(t, want, bindErr, input)
+		}
+	}
+	assert.EqualError(t, &Error{Code: CodeNotInt, Args: []any{"age", "old"}}, `поле "age": "old" не целое число`)
+}
+
+func TestToBSON(t *testing.T) {
+	field := func(name string) string { return name }
+
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"$and": bson.A{
+			bson.M{"age": bson.M{"$gte": int64(18)}},
+			bson.M{"name": bson.M{"$regex": `^Ив.*\..*$`, "$options": "i"}},
+		}},
+		bson.M{"name": "Bob"},
+	}}, ToBSON(bind(t, "age>=18;name=like=Ив*.*,name==Bob"), field))
+
+	// подряд идущие * считаются одним
+	assert.Equal(t, bson.M{"name": bson.M{"$regex": `^.*ва.*$`, "$options": "i"}}, ToBSON(bind(t, "name=like=***ва**"), field))
+
+	assert.Equal(t, bson.M{"age": bson.M{"$nin": []any{int64(1), int64(2)}}}, ToBSON(bind(t, "age=out=(1,2)"), field))
+}
+
+func TestMatch(t *testing.T) {
+	user := map[string]any{
+		"name":       "Иван",
+		"age":        30,
+		"created_at": time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+	}
+	get := func(field string) any { return user[field] }
+
+	cases := map[string]bool{
+		"age>=18;name=like=ив*":           true,
+		"age<18,name==Bob":                false,
+		"age<18,name==Иван":               true,
+		"name!=Иван":                      false,
+		"age=in=(10,30)":                  true,
+		"age=out=(10,30)":                 false,
+		"name=like=*ва*":                  true,
+		"name=like=ва*":                   false,
+		"created_at>2024-01-01T00:00:00Z": true,
+		"(age>40,age<35);name=like=И*":    true,
+	}
+	for input, want := range cases {
+		assert.Equal(t, want, Match(bind(t, input), get), input)
+	}
+}
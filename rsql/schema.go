@@ -0,0 +1,96 @@
+package rsql
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Type - тип поля, определяет разбор значений и допустимые операторы.
+type Type int
+
+const (
+	String Type = iota
+	Int
+	Time
+)
+
+// Schema - поля, разрешенные в фильтре, и их типы.
+type Schema map[string]Type
+
+// MaxLikeWildcards - сколько * может быть в шаблоне =like=. Шаблон уходит
+// в $regex, и каждый лишний .* умножает перебор в регулярном выражении.
+const MaxLikeWildcards = 2
+
+// repeatedWildcards - подряд идущие *, равные одной.
+var repeatedWildcards = regexp.MustCompile(`\*{2,}`)
+
+// Bind проверяет дерево по схеме и заполняет Comparison.Values:
+// string для String, int64 для Int, time.Time (RFC 3339) для Time.
+func Bind(n Node, schema Schema) error {
+	switch n := n.(type) {
+	case *Logical:
+		for _, child := range n.Nodes {
+			if err := Bind(child, schema); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *Comparison:
+		typ, ok := schema[n.Field]
+		if !ok {
+			return &Error{Code: CodeUnknownField, Args: []any{n.Field}}
+		}
+		if n.Op == Like && typ != String {
+			return &Error{Code: CodeLikeNotString, Args: []any{n.Field}}
+		}
+		for _, arg := range n.Args {
+			if !utf8.ValidString(arg) {
+				return &Error{Code: CodeInvalidValue, Args: []any{n.Field}}
+			}
+		}
+		if n.Op == Like {
+			n.Args[0] = repeatedWildcards.ReplaceAllString(n.Args[0], "*")
+			if strings.Count(n.Args[0], "*") > MaxLikeWildcards {
+				return &Error{Code: CodeLikeWildcards, Args: []any{n.Field, MaxLikeWildcards}}
+			}
+			like, err := regexp.Compile("(?i)" + likePattern(n.Args[0]))
+			if err != nil {
+				return &Error{Code: CodeInvalidValue, Args: []any{n.Field}}
+			}
+			n.like = like
+		}
+		n.Values = make([]any, len(n.Args))
+		for i, arg := range n.Args {
+			v, code := typ.parse(arg)
+			if code != "" {
+				return &Error{Code: code, Args: []any{n.Field, arg}}
+			}
+			n.Values[i] = v
+		}
+		return nil
+	}
+	return fmt.Errorf("неизвестный узел %T", n)
+}
+
+// parse разбирает значение по типу; при ошибке возвращает ее код.
+func (t Type) parse(arg string) (any, string) {
+	switch t {
+	case Int:
+		v, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return nil, CodeNotInt
+		}
+		return v, ""
+	case Time:
+		v, err := time.Parse(time.RFC3339, arg)
+		if err != nil {
+			return nil, CodeNotTime
+		}
+		return v, ""
+	}
+	return arg, ""
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// hiddenVars - переменные expvar, которые AdminRouter не отдает: в cmdline
+// лежит os.Args, а в нем могут быть --mongo-uri с паролем и --cursor-secret.
+var hiddenVars = map[string]bool{"cmdline": true}
+
+// AdminRouter возвращает роутер служебного сервера: GET /debug/vars с
+// метриками expvar. Его нужно слушать на отдельном адресе, закрытом снаружи.
+func AdminRouter() *mux.Router {
+	r := mux.NewRouter()
+	r.HandleFunc("/debug/vars", debugVars).Methods("GET")
+	return r
+}
+
+// debugVars повторяет expvar.Handler, пропуская hiddenVars.
+func debugVars(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	fmt.Fprintf(w, "{\n")
+	first := true
+	expvar.Do(func(kv expvar.KeyValue) {
+		if hiddenVars[kv.Key] {
+			return
+		}
+		if !first {
+			fmt.Fprintf(w, ",\n")
+		}
+		first = false
+		fmt.Fprintf(w, "%q: %s", kv.Key, kv.Value)
+	})
+	fmt.Fprintf(w, "\n}\n")
+}
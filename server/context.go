@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// StatusClientClosedRequest - клиент закрыл соединение, не дождавшись ответа
+// (нестандартный код nginx, попадает в логи и метрики, клиент его не увидит).
+const StatusClientClosedRequest = 499
+
+// Имена маршрутов для Options.RouteTimeouts и метрик.
+const (
+	RouteList   = "list"
+	RouteGet    = "get"
+	RouteCreate = "create"
+	RouteUpdate = "update"
+	RoutePatch  = "patch"
+	RouteDelete = "delete"
+)
+
+// cancelled считает операции, прерванные по таймауту (<route>.timeout)
+// и из-за ухода клиента (<route>.canceled). Доступно в GET /debug/vars
+// служебного сервера (AdminRouter).
+var cancelled = expvar.NewMap("usersvc_cancelled_operations")
+
+// requestContext возвращает контекст операции с хранилищем: он отменяется
+// вместе с запросом и ограничен таймаутом маршрута.
+func (s *Server) requestContext(r *http.Request) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(r.Context(), s.routeTimeout(r))
+}
+
+func (s *Server) routeTimeout(r *http.Request) time.Duration {
+	if route := mux.CurrentRoute(r); route != nil {
+		if d, ok := s.opts.RouteTimeouts[route.GetName()]; ok && d > 0 {
+			return d
+		}
+	}
+	return s.opts.Timeout
+}
+
+// contextProblem возвращает 504 или 499, если операция прервана контекстом.
+func contextProblem(ctx context.Context, err error) *Problem {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		err = ctxErr
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return newProblem(CodeTimeout, "")
+	case errors.Is(err, context.Canceled):
+		return newProblem(CodeClientClosed, "")
+	}
+	return nil
+}
+
+// countCancelled обновляет cancelled по коду ответа.
+func countCancelled(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		rw, ok := w.(*responseWriter)
+		if !ok {
+			return
+		}
+		name := "unknown"
+		if route := mux.CurrentRoute(r); route != nil && route.GetName() != "" {
+			name = route.GetName()
+		}
+		switch rw.status {
+		case http.StatusGatewayTimeout:
+			cancelled.Add(name+".timeout", 1)
+		case StatusClientClosedRequest:
+			cancelled.Add(name+".canceled", 1)
+		}
+	})
+}
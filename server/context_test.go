@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lab8/store"
+)
+
+// blockingStore ждет отмены контекста в List, как зависший запрос к бд.
+type blockingStore struct {
+	store.UserStore
+}
+
+func (s blockingStore) List(ctx context.Context, f store.Filter) ([]store.User, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func cancelledCount(key string) int64 {
+	if v, ok := cancelled.Get(key).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+// Тестирование таймаута маршрута и отмены запроса клиентом
+func TestRequestContext(t *testing.T) {
+	s := blockingStore{store.NewMemoryStore(nil, store.User{ID: "1", Name: "Alice", Age: 25})}
+	r := New(s, Options{
+		Timeout:       time.Minute,
+		RouteTimeouts: map[string]time.Duration{RouteList: 10 * time.Millisecond},
+	}).Router()
+
+	timeouts := cancelledCount("list.timeout")
+	req, err := http.NewRequest("GET", "/users", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, rr.Code)
+	var problem Problem
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+	assert.Equal(t, CodeTimeout, problem.Code)
+	assert.Equal(t, timeouts+1, cancelledCount("list.timeout"))
+
+	// клиент ушел до ответа: операция не выполняется
+	cancels := cancelledCount("get.canceled")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, err = http.NewRequestWithContext(ctx, "GET", "/users/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, StatusClientClosedRequest, rr.Code)
+	assert.Equal(t, cancels+1, cancelledCount("get.canceled"))
+
+	// таймаут маршрута list не действует на get
+	req, err = http.NewRequest("GET", "/users/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// метрики отдает только служебный сервер
+	req, err = http.NewRequest("GET", "/debug/vars", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = httptest.NewRecorder()
+	AdminRouter().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var vars map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &vars))
+	assert.Contains(t, vars, "usersvc_cancelled_operations")
+	assert.Contains(t, vars, "memstats")
+	assert.NotContains(t, vars, "cmdline")
+}
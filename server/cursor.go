@@ -0,0 +1,71 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"lab8/store"
+)
+
+var errBadCursor = errors.New("неверный курсор")
+
+// cursorPayload - содержимое токена курсора. Sort фиксирует порядок выдачи,
+// чтобы курсор нельзя было применить к другой сортировке.
+type cursorPayload struct {
+	Sort   string `json:"s"`
+	Values []any  `json:"v,omitempty"`
+	ID     string `json:"id"`
+}
+
+// cursorCodec превращает store.Cursor в непрозрачный токен вида
+// base64(json).base64(hmac-sha256) и обратно.
+type cursorCodec struct {
+	secret []byte
+}
+
+func (c cursorCodec) sign(data []byte) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func (c cursorCodec) encode(cur store.Cursor, sort []store.SortField) string {
+	data, _ := json.Marshal(cursorPayload{Sort: formatSort(sort), Values: cur.Values, ID: cur.ID})
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(data) + "." + enc.EncodeToString(c.sign(data))
+}
+
+// decode проверяет подпись токена и сортировку, для которой он выдан.
+func (c cursorCodec) decode(token string, sort []store.SortField) (store.Cursor, error) {
+	enc := base64.RawURLEncoding
+	body, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return store.Cursor{}, errBadCursor
+	}
+	data, err := enc.DecodeString(body)
+	if err != nil {
+		return store.Cursor{}, errBadCursor
+	}
+	mac, err := enc.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, c.sign(data)) {
+		return store.Cursor{}, errBadCursor
+	}
+
+	var p cursorPayload
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&p); err != nil || p.Sort != formatSort(sort) || len(p.Values) != len(sort) {
+		return store.Cursor{}, errBadCursor
+	}
+	for i, sf := range sort {
+		if p.Values[i], err = store.CursorValue(sf.Field, p.Values[i]); err != nil {
+			return store.Cursor{}, errBadCursor
+		}
+	}
+	return store.Cursor{Values: p.Values, ID: p.ID}, nil
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"lab8/i18n"
+)
+
+// defaultMaxBodyBytes - размер тела запроса по умолчанию, 1 МиБ.
+const defaultMaxBodyBytes = 1 << 20
+
+// readBody читает тело запроса не больше s.opts.MaxBodyBytes байт.
+// На слишком большое тело отвечает 413.
+func (s *Server) readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	// MaxBytesReader просит net/http закрыть соединение после ответа,
+	// только если получит его собственный writer, а не обертку
+	body, err := io.ReadAll(http.MaxBytesReader(unwrap(w), r.Body, s.opts.MaxBodyBytes))
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		writeProblem(w, r, newProblem(CodeBodyTooLarge, tr(r).N("body.too_large", int(tooLarge.Limit))))
+		return nil, false
+	case err != nil:
+		writeProblem(w, r, newProblem(CodeInvalidBody, err.Error()))
+		return nil, false
+	}
+	return body, true
+}
+
+// decodeJSON читает тело application/json в v. Неизвестные поля, данные после
+// JSON и несовпадение типов - 400 с позицией ошибки, другой Content-Type - 415.
+func (s *Server) decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		w.Header().Set("Accept", "application/json")
+		writeProblem(w, r, newProblem(CodeUnsupportedMediaType, tr(r).T("body.content_type")))
+		return false
+	}
+
+	body, ok := s.readBody(w, r)
+	if !ok {
+		return false
+	}
+	if p := decodeStrict(tr(r), body, v); p != nil {
+		writeProblem(w, r, p)
+		return false
+	}
+	return true
+}
+
+// decodeStrict разбирает body в v; сообщения об ошибках - на языке l.
+func decodeStrict(l *i18n.Localizer, body []byte, v any) *Problem {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(v)
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	case err == nil:
+	case errors.Is(err, io.EOF):
+		return newProblem(CodeInvalidBody, l.T("body.empty"))
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		line, col := position(body, int64(len(body)))
+		return newProblem(CodeInvalidBody, l.T("body.truncated", line, col))
+	case errors.As(err, &syntaxErr):
+		// Offset - число прочитанных байт вместе с ошибочным
+		offset := max(syntaxErr.Offset-1, 0)
+		line, col := position(body, offset)
+		return newProblem(CodeInvalidBody, l.T("body.syntax", line, col, offset, syntaxErr.Error()))
+	case errors.As(err, &typeErr):
+		return newProblem(CodeInvalidBody, "", InvalidParam{
+			Name:   typeErr.Field,
+			Reason: l.T("body.type", jsonType(typeErr.Type.Kind().String()), typeErr.Value),
+		})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json не экспортирует тип этой ошибки
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return newProblem(CodeInvalidBody, "", InvalidParam{Name: field, Reason: l.T("body.unknown_field")})
+	default:
+		return newProblem(CodeInvalidBody, err.Error())
+	}
+
+	if offset := dec.InputOffset(); len(bytes.TrimSpace(body[offset:])) > 0 {
+		offset += int64(len(body[offset:]) - len(bytes.TrimLeft(body[offset:], " \t\r\n")))
+		line, col := position(body, offset)
+		return newProblem(CodeInvalidBody, l.T("body.trailing", line, col, offset))
+	}
+	return nil
+}
+
+// position переводит смещение в байтах (с 0) в строку и столбец (с 1).
+func position(body []byte, offset int64) (line, col int) {
+	if offset > int64(len(body)) {
+		offset = int64(len(body))
+	}
+	before := body[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	col = len([]rune(string(before[bytes.LastIndexByte(before, '\n')+1:]))) + 1
+	return line, col
+}
+
+// jsonType называет тип Go так, как он выглядит в JSON.
+func jsonType(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "number"
+	case kind == "bool":
+		return "boolean"
+	case kind == "slice", kind == "array":
+		return "array"
+	case kind == "struct", kind == "map":
+		return "object"
+	}
+	return kind
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тестирование строгого разбора тела POST /users
+func TestDecodeJSON(t *testing.T) {
+	r := newTestRouterWith(Options{MaxBodyBytes: 64})
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		code        Code
+		detail      string
+		params      []InvalidParam
+	}{
+		{"no content type", "", `{"name":"Carol"}`, http.StatusUnsupportedMediaType, CodeUnsupportedMediaType,
+			"Тело запроса должно быть в формате application/json", nil},
+		{"text", "text/plain", `{"name":"Carol"}`, http.StatusUnsupportedMediaType, CodeUnsupportedMediaType,
+			"Тело запроса должно быть в формате application/json", nil},
+		{"too large", "application/json", `{"name":"` + strings.Repeat("a", 64) + `"}`, http.StatusRequestEntityTooLarge, CodeBodyTooLarge,
+			"Максимальный размер тела - 64 байта", nil},
+		{"empty", "application/json", ``, http.StatusBadRequest, CodeInvalidBody,
+			"Пустое тело запроса", nil},
+		{"syntax", "application/json; charset=utf-8", "{\n  \"name\": }", http.StatusBadRequest, CodeInvalidBody,
+			"Ошибка синтаксиса JSON в строке 2, столбце 11 (байт 12): invalid character '}' looking for beginning of value", nil},
+		{"truncated", "application/json", `{"name":"Carol"`, http.StatusBadRequest, CodeInvalidBody,
+			"JSON обрывается в строке 1, столбце 16", nil},
+		{"trailing", "application/json", `{"name":"Carol"} {"name":"Dave"}`, http.StatusBadRequest, CodeInvalidBody,
+			"Лишние данные после JSON в строке 1, столбце 18 (байт 17)", nil},
+		{"unknown field", "application/json", `{"name":"Carol","nickname":"C"}`, http.StatusBadRequest, CodeInvalidBody,
+			"", []InvalidParam{{Name: "nickname", Reason: "Неизвестное поле"}}},
+		{"wrong type", "application/json", `{"name":"Carol","age":"40"}`, http.StatusBadRequest, CodeInvalidBody,
+			"", []InvalidParam{{Name: "age", Reason: "Ожидается значение типа number, получено string"}}},
+		{"ok", "application/json", "{\"name\":\"Carol\"}\n", http.StatusCreated, "", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/users", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", tt.contentType)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			if tt.code == "" {
+				return
+			}
+			var problem Problem
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+			assert.Equal(t, tt.code, problem.Code)
+			assert.Equal(t, tt.detail, problem.Detail)
+			assert.Equal(t, tt.params, problem.InvalidParams)
+		})
+	}
+}
+
+// Тестирование закрытия соединения после слишком большого тела
+func TestBodyTooLargeClosesConnection(t *testing.T) {
+	srv := httptest.NewServer(newTestRouterWith(Options{MaxBodyBytes: 64}))
+	defer srv.Close()
+
+	body := `{"name":"` + strings.Repeat("a", 64) + `"}`
+	resp, err := http.Post(srv.URL+"/users", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	assert.True(t, resp.Close)
+}
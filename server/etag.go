@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"lab8/store"
+)
+
+// etag возвращает сильный ETag для версии пользователя.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// entityTags разбирает список entity-tag из заголовков If-Match или If-None-Match.
+func entityTags(r *http.Request, header string) (tags []string, star bool) {
+	for _, value := range r.Header.Values(header) {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimSpace(tag)
+			switch tag {
+			case "":
+			case "*":
+				star = true
+			default:
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags, star
+}
+
+// ifMatch - условие заголовка If-Match. Слабые ETag (W/"...") никогда не совпадают.
+type ifMatch struct {
+	present  bool
+	star     bool
+	versions []int64
+}
+
+func parseIfMatch(r *http.Request) ifMatch {
+	tags, star := entityTags(r, "If-Match")
+	m := ifMatch{present: star || len(tags) > 0, star: star}
+	for _, tag := range tags {
+		if v, err := strconv.ParseInt(strings.Trim(tag, `"`), 10, 64); err == nil && etag(v) == tag {
+			m.versions = append(m.versions, v)
+		}
+	}
+	return m
+}
+
+func (m ifMatch) matches(version int64) bool {
+	if !m.present || m.star {
+		return true
+	}
+	for _, v := range m.versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// version возвращает версию, которую хранилище должно проверить при записи.
+// Если в If-Match несколько ETag, текущая версия читается из хранилища,
+// а запись затем проверяет, что она не изменилась.
+func (m ifMatch) version(ctx context.Context, s store.UserStore, id string) (int64, error) {
+	switch {
+	case !m.present || m.star:
+		return store.AnyVersion, nil
+	case len(m.versions) == 1:
+		return m.versions[0], nil
+	case len(m.versions) == 0:
+		return 0, store.ErrVersionMismatch
+	}
+	current, err := s.Get(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if !m.matches(current.Version) {
+		return 0, store.ErrVersionMismatch
+	}
+	return current.Version, nil
+}
+
+// notModified проверяет If-None-Match для GET слабым сравнением ETag.
+func notModified(r *http.Request, version int64) bool {
+	tags, star := entityTags(r, "If-None-Match")
+	if star {
+		return true
+	}
+	for _, tag := range tags {
+		if strings.TrimPrefix(tag, "W/") == etag(version) {
+			return true
+		}
+	}
+	return false
+}
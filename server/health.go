@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Имена маршрутов проверки состояния.
+const (
+	RouteHealth = "healthz"
+	RouteReady  = "readyz"
+)
+
+// Состояния в ответах /healthz и /readyz.
+const (
+	statusOK          = "ok"
+	statusUnavailable = "unavailable"
+	statusDraining    = "draining"
+)
+
+// Причины отказа проверки в поле error ответа /readyz. Текст ошибки
+// зависимости (адреса серверов, топология) наружу не отдается, он пишется в лог.
+const (
+	checkTimeout = "timeout"
+	checkFailed  = "failed"
+)
+
+// HealthCheck - проверка зависимости для /readyz, например ping MongoDB.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+	// Details, если задан, возвращает подробности для поля details,
+	// например состояние автомата защиты.
+	Details func() any
+}
+
+// healthResponse - тело ответов /healthz и /readyz.
+type healthResponse struct {
+	Status  string `json:"status"`
+	Version string `json:"version,omitempty"`
+	Store   string `json:"store,omitempty"`
+	// Uptime - время работы в формате time.Duration, UptimeSeconds - в секундах.
+	Uptime        string                 `json:"uptime"`
+	UptimeSeconds int64                  `json:"uptime_seconds"`
+	Checks        map[string]checkResult `json:"checks,omitempty"`
+}
+
+type checkResult struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	// Error - причина отказа: checkTimeout или checkFailed.
+	Error   string `json:"error,omitempty"`
+	Details any    `json:"details,omitempty"`
+}
+
+// Drain переводит /readyz в 503, чтобы балансировщик перестал присылать
+// новые запросы до остановки сервера. Обратно не переключается.
+func (s *Server) Drain() {
+	s.draining.Store(true)
+}
+
+func (s *Server) health(status string) healthResponse {
+	uptime := time.Since(s.started)
+	return healthResponse{
+		Status:        status,
+		Version:       s.opts.Version,
+		Store:         s.opts.StoreType,
+		Uptime:        uptime.Truncate(time.Second).String(),
+		UptimeSeconds: int64(uptime.Seconds()),
+	}
+}
+
+// healthz - проверка живости: процесс отвечает на запросы. Зависимости
+// не проверяются, чтобы недоступная база не приводила к перезапуску.
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	respond(w, http.StatusOK, s.health(statusOK))
+}
+
+// readyz - проверка готовности: все зависимости отвечают и сервер не
+// останавливается. Иначе 503 с состоянием каждой зависимости.
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := s.requestContext(r)
+	defer cancel()
+
+	checks := make(map[string]checkResult, len(s.opts.Checks))
+	status := statusOK
+	for _, c := range s.opts.Checks {
+		start := time.Now()
+		err := c.Check(ctx)
+		result := checkResult{Status: statusOK, LatencyMS: time.Since(start).Milliseconds()}
+		if err != nil {
+			result.Status = statusUnavailable
+			result.Error = checkFailed
+			if errors.Is(err, context.DeadlineExceeded) {
+				result.Error = checkTimeout
+			}
+			slog.WarnContext(r.Context(), "Проверка готовности не пройдена", "check", c.Name, "error", err)
+			status = statusUnavailable
+		}
+		if c.Details != nil {
+			result.Details = c.Details()
+		}
+		checks[c.Name] = result
+	}
+	if s.draining.Load() {
+		status = statusDraining
+	}
+
+	resp := s.health(status)
+	resp.Checks = checks
+	code := http.StatusOK
+	if status != statusOK {
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	respond(w, code, resp)
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lab8/store"
+)
+
+func getHealth(t *testing.T, h http.Handler, path string) (int, healthResponse) {
+	t.Helper()
+	req, err := http.NewRequest("GET", path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
+	var resp healthResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	return rr.Code, resp
+}
+
+// Тестирование /healthz и /readyz: состояние зависимостей и остановка
+func TestHealth(t *testing.T) {
+	var dbErr error
+	s := New(store.NewMemoryStore(nil), Options{
+		Version:   "1.2.3",
+		StoreType: "mongo",
+		Checks: []HealthCheck{{
+			Name:    "mongo",
+			Check:   func(ctx context.Context) error { return dbErr },
+			Details: func() any { return map[string]string{"breaker": "closed"} },
+		}},
+	})
+	r := s.Router()
+
+	code, resp := getHealth(t, r, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", resp.Status)
+	assert.Equal(t, "1.2.3", resp.Version)
+	assert.Equal(t, "mongo", resp.Store)
+	assert.NotEmpty(t, resp.Uptime)
+	assert.Empty(t, resp.Checks)
+
+	code, resp = getHealth(t, r, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", resp.Status)
+	assert.Equal(t, "ok", resp.Checks["mongo"].Status)
+	assert.Equal(t, map[string]any{"breaker": "closed"}, resp.Checks["mongo"].Details)
+
+	// база недоступна: не готов, но жив
+	dbErr = errors.New("server selection timeout")
+	code, resp = getHealth(t, r, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unavailable", resp.Status)
+	assert.Equal(t, "unavailable", resp.Checks["mongo"].Status)
+	assert.Equal(t, "failed", resp.Checks["mongo"].Error)
+
+	code, _ = getHealth(t, r, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+
+	// при остановке /readyz отвечает 503 даже при доступной базе
+	dbErr = nil
+	s.Drain()
+	code, resp = getHealth(t, r, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "draining", resp.Status)
+	assert.Equal(t, "ok", resp.Checks["mongo"].Status)
+}
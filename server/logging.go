@@ -0,0 +1,96 @@
+package server
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// AccessLog - настройки журнала запросов.
+type AccessLog struct {
+	// Logger - куда писать записи, nil - журнал выключен.
+	Logger *slog.Logger
+	// SampleRate - доля запросов с кодом меньше 400, попадающих в журнал,
+	// от 0 до 1. В отличие от других настроек 0 не заменяется: пишутся
+	// только ошибки. Ответы 4xx и 5xx пишутся всегда.
+	SampleRate float64
+	// Redact - параметры запроса, значения которых заменяются на REDACTED.
+	Redact []string
+}
+
+// redacted - замена скрытого значения в журнале; без символов,
+// которые url.Values.Encode экранирует.
+const redacted = "REDACTED"
+
+// logRequests пишет запись о каждом запросе: 5xx - уровнем Error, 4xx - Warn,
+// остальные - Info, а успешные проверки состояния - Debug, чтобы пробы
+// балансировщика не забивали журнал. request_id добавляет обработчик
+// requestid.Handler из контекста запроса.
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	log := s.opts.AccessLog
+	if log.Logger == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		latency := time.Since(start)
+
+		rw, ok := w.(*responseWriter)
+		if !ok {
+			return
+		}
+		status := rw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if status < 400 && rand.Float64() >= log.SampleRate {
+			return
+		}
+
+		route, name := "", ""
+		if current := mux.CurrentRoute(r); current != nil {
+			route, _ = current.GetPathTemplate()
+			name = current.GetName()
+		}
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		case name == RouteHealth || name == RouteReady:
+			level = slog.LevelDebug
+		}
+
+		log.Logger.LogAttrs(r.Context(), level, "request",
+			slog.String("method", r.Method),
+			slog.String("route", route),
+			slog.String("path", r.URL.Path),
+			slog.String("query", redactQuery(r.URL.Query(), log.Redact)),
+			slog.Int("status", status),
+			slog.Int64("bytes", rw.bytes),
+			slog.Float64("latency_ms", float64(latency.Microseconds())/1000),
+			slog.String("remote_addr", r.RemoteAddr),
+			slog.String("user_agent", r.UserAgent()),
+		)
+	})
+}
+
+// redactQuery возвращает строку запроса, в которой значения параметров
+// из redact заменены на REDACTED.
+func redactQuery(query url.Values, redact []string) string {
+	for name, values := range query {
+		if slices.Contains(redact, name) {
+			for i := range values {
+				values[i] = redacted
+			}
+		}
+	}
+	return query.Encode()
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lab8/store"
+)
+
+// logRecords разбирает записи slog в формате JSON.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &rec))
+		records = append(records, rec)
+	}
+	buf.Reset()
+	return records
+}
+
+// Тестирование журнала запросов: поля, уровни, выборка и скрытие параметров
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	s := store.NewMemoryStore(nil, store.User{ID: "1", Name: "Alice", Age: 25})
+	r := New(s, Options{AccessLog: AccessLog{
+		Logger:     logger,
+		SampleRate: 1,
+		Redact:     []string{"name"},
+	}}).Router()
+
+	serve := func(target string) {
+		req, err := http.NewRequest("GET", target, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("User-Agent", "usersvc-test")
+		req.Header.Set("X-Request-ID", "req-1")
+		req.RemoteAddr = "10.0.0.1:5000"
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	serve("/users/1?name=Alice&limit=5")
+	records := logRecords(t, &buf)
+	require.Len(t, records, 1)
+	rec := records[0]
+	assert.Equal(t, "INFO", rec["level"])
+	assert.Equal(t, "request", rec["msg"])
+	assert.Equal(t, "GET", rec["method"])
+	assert.Equal(t, "/users/{id}", rec["route"])
+	assert.Equal(t, "/users/1", rec["path"])
+	assert.Equal(t, "limit=5&name=REDACTED", rec["query"])
+	assert.Equal(t, 200.0, rec["status"])
+	assert.Greater(t, rec["bytes"], 0.0)
+	assert.Contains(t, rec, "latency_ms")
+	assert.Equal(t, "req-1", rec["request_id"])
+	assert.Equal(t, "10.0.0.1:5000", rec["remote_addr"])
+	assert.Equal(t, "usersvc-test", rec["user_agent"])
+
+	serve("/groups")
+	records = logRecords(t, &buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, "WARN", records[0]["level"])
+	assert.Equal(t, 404.0, records[0]["status"])
+	assert.Equal(t, "", records[0]["route"])
+
+	serve("/healthz")
+	records = logRecords(t, &buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, "DEBUG", records[0]["level"])
+
+	// при SampleRate 0 пишутся только ошибки
+	r = New(s, Options{AccessLog: AccessLog{Logger: logger}}).Router()
+	serve("/users/1")
+	assert.Empty(t, logRecords(t, &buf))
+	serve("/users/99")
+	records = logRecords(t, &buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, 404.0, records[0]["status"])
+}
@@ -0,0 +1,273 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"lab8/i18n"
+	"lab8/jsonpatch"
+	"lab8/rsql"
+)
+
+// messages - тексты ответов. Русский - язык по умолчанию.
+var messages = i18n.NewCatalog(ru, en)
+
+var ru = &i18n.Bundle{
+	Lang:   "ru",
+	Plural: i18n.PluralRu,
+	Messages: map[string]i18n.Message{
+		"problem.invalid_id":             {i18n.Other: "Неправильный ID"},
+		"problem.invalid_parameter":      {i18n.Other: "Неверный параметр запроса"},
+		"problem.invalid_body":           {i18n.Other: "Неправильные данные"},
+		"problem.body_too_large":         {i18n.Other: "Слишком большое тело запроса"},
+		"problem.validation_failed":      {i18n.Other: "Данные пользователя не прошли проверку"},
+		"problem.user_not_found":         {i18n.Other: "Пользователь не найден"},
+		"problem.route_not_found":        {i18n.Other: "Ресурс не найден"},
+		"problem.method_not_allowed":     {i18n.Other: "Метод не поддерживается"},
+		"problem.version_mismatch":       {i18n.Other: "Версия пользователя изменилась"},
+		"problem.conflict":               {i18n.Other: "Пользователь изменен другим запросом, повторите попытку"},
+		"problem.unsupported_media_type": {i18n.Other: "Неподдерживаемый формат тела"},
+		"problem.invalid_patch":          {i18n.Other: "Неправильный патч"},
+		"problem.patch_test_failed":      {i18n.Other: "Патч не применен"},
+		"problem.patch_unprocessable":    {i18n.Other: "Патч не применим"},
+		"problem.timeout":                {i18n.Other: "Превышено время ожидания"},
+		"problem.client_closed_request":  {i18n.Other: "Клиент закрыл соединение"},
+		"problem.store_unavailable":      {i18n.Other: "База данных временно недоступна, повторите запрос позже"},
+		"problem.internal_error":         {i18n.Other: "Внутренняя ошибка сервера"},
+
+		"param.invalid":        {i18n.Other: "Неверное значение %s"},
+		"param.page_cursor":    {i18n.Other: "Параметры page и cursor несовместимы"},
+		"param.max":            {i18n.Other: "Максимальное значение - %d"},
+		"param.page_too_large": {i18n.Other: "Слишком большой номер страницы"},
+		"param.too_long": {
+			i18n.One:  "Максимальная длина - %d символ",
+			i18n.Few:  "Максимальная длина - %d символа",
+			i18n.Many: "Максимальная длина - %d символов",
+		},
+
+		"db.read":   {i18n.Other: "Ошибка чтения из бд"},
+		"db.count":  {i18n.Other: "Ошибка при подсчете данных"},
+		"db.create": {i18n.Other: "Ошибка при добавлении пользователя"},
+		"db.update": {i18n.Other: "Ошибка при обновлении данных"},
+		"db.delete": {i18n.Other: "Ошибка при удалении пользователя"},
+
+		"user.updated": {i18n.Other: "Пользователь обновлен"},
+
+		"body.content_type":  {i18n.Other: "Тело запроса должно быть в формате application/json"},
+		"body.empty":         {i18n.Other: "Пустое тело запроса"},
+		"body.truncated":     {i18n.Other: "JSON обрывается в строке %d, столбце %d"},
+		"body.syntax":        {i18n.Other: "Ошибка синтаксиса JSON в строке %d, столбце %d (байт %d): %s"},
+		"body.trailing":      {i18n.Other: "Лишние данные после JSON в строке %d, столбце %d (байт %d)"},
+		"body.type":          {i18n.Other: "Ожидается значение типа %s, получено %s"},
+		"body.unknown_field": {i18n.Other: "Неизвестное поле"},
+		"body.too_large": {
+			i18n.One:  "Максимальный размер тела - %d байт",
+			i18n.Few:  "Максимальный размер тела - %d байта",
+			i18n.Many: "Максимальный размер тела - %d байт",
+		},
+
+		"validation.required":   {i18n.Other: "Поле обязательно"},
+		"validation.empty":      {i18n.Other: "Поле заполняет сервер, передавать его нельзя"},
+		"validation.min":        {i18n.Other: "Значение не меньше %d"},
+		"validation.max":        {i18n.Other: "Значение не больше %d"},
+		"validation.email":      {i18n.Other: "Неправильный адрес электронной почты"},
+		"validation.personname": {i18n.Other: "Имя должно начинаться с буквы и состоять из букв, пробелов, дефисов, апострофов и точек"},
+		"validation.minlen": {
+			i18n.One:  "Минимальная длина - %d символ",
+			i18n.Few:  "Минимальная длина - %d символа",
+			i18n.Many: "Минимальная длина - %d символов",
+		},
+		"validation.maxlen": {
+			i18n.One:  "Максимальная длина - %d символ",
+			i18n.Few:  "Максимальная длина - %d символа",
+			i18n.Many: "Максимальная длина - %d символов",
+		},
+		"validation.name.required": {i18n.Other: "Имя не может быть пустым"},
+
+		"patch.unsupported":    {i18n.Other: "Неподдерживаемый формат патча"},
+		"patch.invalid_result": {i18n.Other: "Неправильные данные после патча: %v"},
+		"patch.immutable":      {i18n.Other: "Поля id, created_at и version нельзя изменить"},
+
+		"sort.unknown_field": {i18n.Other: "Сортировка по полю %q недоступна"},
+		"sort.duplicate":     {i18n.Other: "Поле %q указано в sort дважды"},
+		"fields.unknown":     {i18n.Other: "Неизвестное поле %q"},
+
+		"rsql.syntax":            {i18n.Other: "Позиция %d: %s"},
+		"rsql.unexpected_char":   {i18n.Other: "лишний символ %q"},
+		"rsql.expected_paren":    {i18n.Other: "ожидается )"},
+		"rsql.expected_field":    {i18n.Other: "ожидается имя поля"},
+		"rsql.expected_operator": {i18n.Other: "ожидается оператор сравнения"},
+		"rsql.expected_value":    {i18n.Other: "ожидается значение"},
+		"rsql.unclosed_quote":    {i18n.Other: "незакрытая кавычка"},
+		"rsql.single_value":      {i18n.Other: "оператор %s принимает одно значение"},
+		"rsql.unknown_field":     {i18n.Other: "Поле %q недоступно для фильтрации"},
+		"rsql.like_not_string":   {i18n.Other: "Оператор =like= применим только к строковым полям, а %q не строка"},
+		"rsql.like_wildcards":    {i18n.Other: "Поле %q: шаблон =like= может содержать не больше %d символов *"},
+		"rsql.not_int":           {i18n.Other: "Поле %q: %q не целое число"},
+		"rsql.not_time":          {i18n.Other: "Поле %q: %q не время в формате RFC 3339"},
+		"rsql.invalid_value":     {i18n.Other: "Поле %q: недопустимое значение"},
+
+		"jsonpatch.op":             {i18n.Other: "Операция %d (%s %s): %s"},
+		"jsonpatch.invalid_json":   {i18n.Other: "Патч не разбирается как JSON: %s"},
+		"jsonpatch.trailing_data":  {i18n.Other: "Лишние данные после JSON"},
+		"jsonpatch.no_value":       {i18n.Other: "нет value"},
+		"jsonpatch.unknown_op":     {i18n.Other: "неизвестная операция %q"},
+		"jsonpatch.bad_pointer":    {i18n.Other: "путь %q должен начинаться с /"},
+		"jsonpatch.move_into_self": {i18n.Other: "нельзя переместить значение внутрь самого себя"},
+		"jsonpatch.no_field":       {i18n.Other: "нет поля %q"},
+		"jsonpatch.scalar":         {i18n.Other: "%q внутри скалярного значения"},
+		"jsonpatch.bad_index":      {i18n.Other: "неверный индекс %q"},
+		"jsonpatch.test_failed":    {i18n.Other: "значение не совпадает"},
+	},
+}
+
+var en = &i18n.Bundle{
+	Lang:   "en",
+	Plural: i18n.PluralEn,
+	Messages: map[string]i18n.Message{
+		"problem.invalid_id":             {i18n.Other: "Invalid ID"},
+		"problem.invalid_parameter":      {i18n.Other: "Invalid query parameter"},
+		"problem.invalid_body":           {i18n.Other: "Invalid request body"},
+		"problem.body_too_large":         {i18n.Other: "Request body too large"},
+		"problem.validation_failed":      {i18n.Other: "User validation failed"},
+		"problem.user_not_found":         {i18n.Other: "User not found"},
+		"problem.route_not_found":        {i18n.Other: "Resource not found"},
+		"problem.method_not_allowed":     {i18n.Other: "Method not allowed"},
+		"problem.version_mismatch":       {i18n.Other: "User version has changed"},
+		"problem.conflict":               {i18n.Other: "User was modified by another request, please retry"},
+		"problem.unsupported_media_type": {i18n.Other: "Unsupported body format"},
+		"problem.invalid_patch":          {i18n.Other: "Invalid patch"},
+		"problem.patch_test_failed":      {i18n.Other: "Patch was not applied"},
+		"problem.patch_unprocessable":    {i18n.Other: "Patch cannot be applied"},
+		"problem.timeout":                {i18n.Other: "Request timed out"},
+		"problem.client_closed_request":  {i18n.Other: "Client closed the request"},
+		"problem.store_unavailable":      {i18n.Other: "Database is temporarily unavailable, please retry later"},
+		"problem.internal_error":         {i18n.Other: "Internal server error"},
+
+		"param.invalid":        {i18n.Other: "Invalid value of %s"},
+		"param.page_cursor":    {i18n.Other: "Parameters page and cursor cannot be combined"},
+		"param.max":            {i18n.Other: "Maximum value is %d"},
+		"param.page_too_large": {i18n.Other: "Page number is too large"},
+		"param.too_long": {
+			i18n.One:   "Maximum length is %d character",
+			i18n.Other: "Maximum length is %d characters",
+		},
+
+		"db.read":   {i18n.Other: "Failed to read from the database"},
+		"db.count":  {i18n.Other: "Failed to count users"},
+		"db.create": {i18n.Other: "Failed to create the user"},
+		"db.update": {i18n.Other: "Failed to update the user"},
+		"db.delete": {i18n.Other: "Failed to delete the user"},
+
+		"user.updated": {i18n.Other: "User updated"},
+
+		"body.content_type":  {i18n.Other: "Request body must be application/json"},
+		"body.empty":         {i18n.Other: "Request body is empty"},
+		"body.truncated":     {i18n.Other: "JSON ends unexpectedly at line %d, column %d"},
+		"body.syntax":        {i18n.Other: "JSON syntax error at line %d, column %d (byte %d): %s"},
+		"body.trailing":      {i18n.Other: "Unexpected data after JSON at line %d, column %d (byte %d)"},
+		"body.type":          {i18n.Other: "Expected a value of type %s, got %s"},
+		"body.unknown_field": {i18n.Other: "Unknown field"},
+		"body.too_large": {
+			i18n.One:   "Maximum body size is %d byte",
+			i18n.Other: "Maximum body size is %d bytes",
+		},
+
+		"validation.required":   {i18n.Other: "Field is required"},
+		"validation.empty":      {i18n.Other: "Field is set by the server and must not be sent"},
+		"validation.min":        {i18n.Other: "Value must be at least %d"},
+		"validation.max":        {i18n.Other: "Value must be at most %d"},
+		"validation.email":      {i18n.Other: "Invalid email address"},
+		"validation.personname": {i18n.Other: "Name must start with a letter and contain only letters, spaces, hyphens, apostrophes and dots"},
+		"validation.minlen": {
+			i18n.One:   "Minimum length is %d character",
+			i18n.Other: "Minimum length is %d characters",
+		},
+		"validation.maxlen": {
+			i18n.One:   "Maximum length is %d character",
+			i18n.Other: "Maximum length is %d characters",
+		},
+		"validation.name.required": {i18n.Other: "Name must not be empty"},
+
+		"patch.unsupported":    {i18n.Other: "Unsupported patch format"},
+		"patch.invalid_result": {i18n.Other: "Invalid user after patch: %v"},
+		"patch.immutable":      {i18n.Other: "Fields id, created_at and version cannot be changed"},
+
+		"sort.unknown_field": {i18n.Other: "Sorting by field %q is not available"},
+		"sort.duplicate":     {i18n.Other: "Field %q is listed in sort twice"},
+		"fields.unknown":     {i18n.Other: "Unknown field %q"},
+
+		"rsql.syntax":            {i18n.Other: "Position %d: %s"},
+		"rsql.unexpected_char":   {i18n.Other: "unexpected character %q"},
+		"rsql.expected_paren":    {i18n.Other: ") expected"},
+		"rsql.expected_field":    {i18n.Other: "field name expected"},
+		"rsql.expected_operator": {i18n.Other: "comparison operator expected"},
+		"rsql.expected_value":    {i18n.Other: "value expected"},
+		"rsql.unclosed_quote":    {i18n.Other: "unclosed quote"},
+		"rsql.single_value":      {i18n.Other: "operator %s takes a single value"},
+		"rsql.unknown_field":     {i18n.Other: "Field %q cannot be used in filter"},
+		"rsql.like_not_string":   {i18n.Other: "Operator =like= applies only to string fields, %q is not a string"},
+		"rsql.like_wildcards":    {i18n.Other: "Field %q: a =like= pattern may contain at most %d * wildcards"},
+		"rsql.not_int":           {i18n.Other: "Field %q: %q is not an integer"},
+		"rsql.not_time":          {i18n.Other: "Field %q: %q is not an RFC 3339 time"},
+		"rsql.invalid_value":     {i18n.Other: "Field %q: invalid value"},
+
+		"jsonpatch.op":             {i18n.Other: "Operation %d (%s %s): %s"},
+		"jsonpatch.invalid_json":   {i18n.Other: "Patch is not valid JSON: %s"},
+		"jsonpatch.trailing_data":  {i18n.Other: "Unexpected data after JSON"},
+		"jsonpatch.no_value":       {i18n.Other: "value is missing"},
+		"jsonpatch.unknown_op":     {i18n.Other: "unknown operation %q"},
+		"jsonpatch.bad_pointer":    {i18n.Other: "path %q must start with /"},
+		"jsonpatch.move_into_self": {i18n.Other: "cannot move a value into itself"},
+		"jsonpatch.no_field":       {i18n.Other: "field %q does not exist"},
+		"jsonpatch.scalar":         {i18n.Other: "%q is inside a scalar value"},
+		"jsonpatch.bad_index":      {i18n.Other: "invalid index %q"},
+		"jsonpatch.test_failed":    {i18n.Other: "value does not match"},
+	},
+}
+
+type localizerKey struct{}
+
+// localize выбирает язык ответа по Accept-Language и кладет его в контекст запроса.
+func localize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := messages.Match(r.Header.Get("Accept-Language"))
+		w.Header().Set("Content-Language", l.Lang())
+		w.Header().Add("Vary", "Accept-Language")
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), localizerKey{}, l)))
+	})
+}
+
+// tr возвращает сообщения на языке запроса.
+func tr(r *http.Request) *i18n.Localizer {
+	if l, ok := r.Context().Value(localizerKey{}).(*i18n.Localizer); ok {
+		return l
+	}
+	return messages.Match(r.Header.Get("Accept-Language"))
+}
+
+// errorReason возвращает текст ошибки разбора sort, fields, filter или
+// JSON Patch на языке l. Остальные ошибки отдаются как есть.
+func errorReason(l *i18n.Localizer, err error) string {
+	var (
+		param     *paramError
+		syntaxErr *rsql.SyntaxError
+		filterErr *rsql.Error
+		opErr     *jsonpatch.OpError
+		patchErr  *jsonpatch.Error
+	)
+	switch {
+	case errors.As(err, &param):
+		return l.T(param.key, param.args...)
+	case errors.As(err, &syntaxErr):
+		return l.T("rsql.syntax", syntaxErr.Pos, l.T("rsql."+syntaxErr.Code, syntaxErr.Args...))
+	case errors.As(err, &filterErr):
+		return l.T("rsql."+filterErr.Code, filterErr.Args...)
+	case errors.As(err, &opErr):
+		return l.T("jsonpatch.op", opErr.Index, opErr.Op, opErr.Path, errorReason(l, opErr.Err))
+	case errors.As(err, &patchErr):
+		return l.T("jsonpatch."+patchErr.Code, patchErr.Args...)
+	}
+	return err.Error()
+}
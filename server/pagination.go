@@ -0,0 +1,68 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// userPage - ответ GET /users при включенном Options.ListEnvelope.
+type userPage struct {
+	Items      any   `json:"items"`
+	Total      int64 `json:"total"`
+	Page       int   `json:"page"`
+	Limit      int   `json:"limit"`
+	TotalPages int   `json:"total_pages"`
+}
+
+// newUserPage собирает страницу; users - результат project.
+func newUserPage(users any, total int64, page, limit int) userPage {
+	return userPage{
+		Items:      users,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	}
+}
+
+// links возвращает заголовок Link (RFC 8288) со ссылками first, prev, next и last.
+// Остальные параметры запроса сохраняются.
+func (p userPage) links(u *url.URL) string {
+	pageURL := func(page int) string {
+		q := u.Query()
+		q.Set("page", strconv.Itoa(page))
+		q.Set("limit", strconv.Itoa(p.Limit))
+		return (&url.URL{Path: u.Path, RawQuery: q.Encode()}).String()
+	}
+
+	last := max(p.TotalPages, 1)
+	links := []string{fmt.Sprintf(`<%s>; rel="first"`, pageURL(1))}
+	if p.Page > 1 {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, pageURL(min(p.Page-1, last))))
+	}
+	if p.Page < last {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(p.Page+1)))
+	}
+	links = append(links, fmt.Sprintf(`<%s>; rel="last"`, pageURL(last)))
+	return strings.Join(links, ", ")
+}
+
+// cursorPage - ответ GET /users при keyset-пагинации.
+type cursorPage struct {
+	Items      any    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func (p cursorPage) nextURL(u *url.URL) string {
+	q := u.Query()
+	q.Set("cursor", p.NextCursor)
+	return (&url.URL{Path: u.Path, RawQuery: q.Encode()}).String()
+}
+
+func (p userPage) writeHeaders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Link", p.links(r.URL))
+	w.Header().Set("X-Total-Count", strconv.FormatInt(p.Total, 10))
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+
+	"lab8/i18n"
+	"lab8/jsonpatch"
+	"lab8/store"
+	"lab8/validate"
+)
+
+// acceptPatch - форматы тела PATCH /users/{id}.
+const acceptPatch = "application/merge-patch+json, application/json-patch+json"
+
+// applyPatch применяет патч к пользователю через его JSON-представление
+// и проверяет результат так же, как тело PUT.
+func (s *Server) applyPatch(l *i18n.Localizer, u store.User, patch []byte, apply func(doc, patch []byte) ([]byte, error)) (store.User, error) {
+	doc, err := json.Marshal(u)
+	if err != nil {
+		return store.User{}, err
+	}
+
+	patched, err := apply(doc, patch)
+	switch {
+	case errors.Is(err, jsonpatch.ErrInvalid):
+		return store.User{}, newProblem(CodeInvalidPatch, errorReason(l, err))
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		return store.User{}, newProblem(CodePatchTestFailed, errorReason(l, err))
+	case err != nil:
+		return store.User{}, newProblem(CodePatchUnprocessable, errorReason(l, err))
+	}
+
+	var next store.User
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&next); err != nil {
+		return store.User{}, newProblem(CodePatchUnprocessable, l.T("patch.invalid_result", err))
+	}
+	if next.ID != u.ID || !next.CreatedAt.Equal(u.CreatedAt) || next.Version != u.Version {
+		return store.User{}, newProblem(CodePatchUnprocessable, l.T("patch.immutable"))
+	}
+	if params := s.validateUser(l, next, validate.Update); params != nil {
+		return store.User{}, newProblem(CodeValidationFailed, "", params...)
+	}
+	return next, nil
+}
+
+// patchUser частично обновляет пользователя: JSON Merge Patch (RFC 7396)
+// или JSON Patch (RFC 6902) в зависимости от Content-Type.
+func (s *Server) patchUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.userID(w, r)
+	if !ok {
+		return
+	}
+
+	var apply func(doc, patch []byte) ([]byte, error)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/merge-patch+json":
+		apply = jsonpatch.MergePatch
+	case "application/json-patch+json":
+		apply = jsonpatch.Apply
+	default:
+		w.Header().Set("Accept-Patch", acceptPatch)
+		writeProblem(w, r, newProblem(CodeUnsupportedMediaType, tr(r).T("patch.unsupported")))
+		return
+	}
+
+	patch, ok := s.readBody(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := s.requestContext(r)
+	defer cancel()
+
+	precondition := parseIfMatch(r)
+	user, err := s.store.Patch(ctx, id, func(u store.User) (store.User, error) {
+		if !precondition.matches(u.Version) {
+			return store.User{}, store.ErrVersionMismatch
+		}
+		return s.applyPatch(tr(r), u, patch, apply)
+	})
+	if err != nil {
+		writeProblem(w, r, storeProblem(ctx, err, tr(r).T("db.update")))
+		return
+	}
+
+	w.Header().Set("ETag", etag(user.Version))
+	respond(w, http.StatusOK, user)
+}
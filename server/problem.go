@@ -0,0 +1,201 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"lab8/requestid"
+	"lab8/store"
+)
+
+// Code - стабильный машиночитаемый код ошибки. Клиенты различают ошибки
+// по нему, а не по тексту title и detail.
+type Code string
+
+const (
+	CodeInvalidID            Code = "invalid_id"
+	CodeInvalidParameter     Code = "invalid_parameter"
+	CodeInvalidBody          Code = "invalid_body"
+	CodeBodyTooLarge         Code = "body_too_large"
+	CodeValidationFailed     Code = "validation_failed"
+	CodeUserNotFound         Code = "user_not_found"
+	CodeRouteNotFound        Code = "route_not_found"
+	CodeMethodNotAllowed     Code = "method_not_allowed"
+	CodeVersionMismatch      Code = "version_mismatch"
+	CodeConflict             Code = "conflict"
+	CodeUnsupportedMediaType Code = "unsupported_media_type"
+	CodeInvalidPatch         Code = "invalid_patch"
+	CodePatchTestFailed      Code = "patch_test_failed"
+	CodePatchUnprocessable   Code = "patch_unprocessable"
+	CodeTimeout              Code = "timeout"
+	CodeClientClosed         Code = "client_closed_request"
+	CodeUnavailable          Code = "store_unavailable"
+	CodeInternal             Code = "internal_error"
+)
+
+// statuses - каталог ошибок: код ответа для каждого кода ошибки.
+// Заголовки title лежат в messages под ключами problem.<code>.
+var statuses = map[Code]int{
+	CodeInvalidID:            http.StatusBadRequest,
+	CodeInvalidParameter:     http.StatusBadRequest,
+	CodeInvalidBody:          http.StatusBadRequest,
+	CodeBodyTooLarge:         http.StatusRequestEntityTooLarge,
+	CodeValidationFailed:     http.StatusBadRequest,
+	CodeUserNotFound:         http.StatusNotFound,
+	CodeRouteNotFound:        http.StatusNotFound,
+	CodeMethodNotAllowed:     http.StatusMethodNotAllowed,
+	CodeVersionMismatch:      http.StatusPreconditionFailed,
+	CodeConflict:             http.StatusConflict,
+	CodeUnsupportedMediaType: http.StatusUnsupportedMediaType,
+	CodeInvalidPatch:         http.StatusBadRequest,
+	CodePatchTestFailed:      http.StatusConflict,
+	CodePatchUnprocessable:   http.StatusUnprocessableEntity,
+	CodeTimeout:              http.StatusGatewayTimeout,
+	CodeClientClosed:         StatusClientClosedRequest,
+	CodeUnavailable:          http.StatusServiceUnavailable,
+	CodeInternal:             http.StatusInternalServerError,
+}
+
+// problemTypePrefix - префикс поля type; тип ошибки однозначно задается кодом.
+const problemTypePrefix = "urn:usersvc:problem:"
+
+// Problem - тело ошибки в формате application/problem+json (RFC 7807).
+type Problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	Instance      string         `json:"instance,omitempty"`
+	Code          Code           `json:"code"`
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+	// RequestID - X-Request-ID запроса, по нему ошибка находится в журнале.
+	RequestID string `json:"request_id,omitempty"`
+
+	// retryAfter - значение заголовка Retry-After, если больше нуля.
+	retryAfter time.Duration
+}
+
+// InvalidParam - ошибка в одном поле тела или параметре запроса.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// newProblem собирает ошибку по коду из каталога.
+func newProblem(code Code, detail string, params ...InvalidParam) *Problem {
+	status, ok := statuses[code]
+	if !ok {
+		code, status = CodeInternal, statuses[CodeInternal]
+	}
+	return &Problem{
+		Type:          problemTypePrefix + string(code),
+		Status:        status,
+		Detail:        detail,
+		Code:          code,
+		InvalidParams: params,
+	}
+}
+
+// Problem реализует error, чтобы ошибку можно было вернуть из функции
+// изменения в store.UserStore.Patch.
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return string(p.Code) + ": " + p.Detail
+	}
+	return string(p.Code)
+}
+
+// writeProblem отправляет ошибку с title на языке запроса, instance,
+// равным пути запроса, и request_id.
+func writeProblem(w http.ResponseWriter, r *http.Request, p *Problem) {
+	p.Title = tr(r).T("problem." + string(p.Code))
+	p.RequestID = requestid.FromContext(r.Context())
+	if p.Instance == "" {
+		p.Instance = r.URL.Path
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	if p.retryAfter > 0 {
+		// Retry-After - целое число секунд, округляем вверх
+		w.Header().Set("Retry-After", strconv.Itoa(int((p.retryAfter+time.Second-1)/time.Second)))
+	}
+	respond(w, p.Status, p)
+}
+
+// invalidParameter отвечает 400 на неверный параметр запроса.
+func invalidParameter(w http.ResponseWriter, r *http.Request, name, reason string) {
+	writeProblem(w, r, newProblem(CodeInvalidParameter, "", InvalidParam{Name: name, Reason: reason}))
+}
+
+// defaultRetryAfter - Retry-After для недоступного хранилища, которое не
+// сообщило, когда повторить запрос.
+const defaultRetryAfter = 5 * time.Second
+
+// storeProblem переводит ошибку хранилища в Problem. Операции, прерванные
+// контекстом ctx, дают 504 или 499, недоступное хранилище - 503 с Retry-After,
+// неизвестные ошибки - internal_error с detail, текст самой ошибки наружу не уходит.
+func storeProblem(ctx context.Context, err error, detail string) *Problem {
+	if p := contextProblem(ctx, err); p != nil {
+		return p
+	}
+	var (
+		p           *Problem
+		unavailable *store.UnavailableError
+	)
+	switch {
+	case errors.As(err, &p):
+		return p
+	case errors.Is(err, store.ErrUnavailable):
+		p := newProblem(CodeUnavailable, "")
+		p.retryAfter = defaultRetryAfter
+		if errors.As(err, &unavailable) && unavailable.RetryAfter > 0 {
+			p.retryAfter = unavailable.RetryAfter
+		}
+		return p
+	case errors.Is(err, store.ErrInvalidID):
+		return newProblem(CodeInvalidID, "")
+	case errors.Is(err, store.ErrNotFound):
+		return newProblem(CodeUserNotFound, "")
+	case errors.Is(err, store.ErrVersionMismatch):
+		return newProblem(CodeVersionMismatch, "")
+	case errors.Is(err, store.ErrConflict):
+		return newProblem(CodeConflict, "")
+	default:
+		return newProblem(CodeInternal, detail)
+	}
+}
+
+// notFound и methodNotAllowed отвечают на запросы мимо маршрутов.
+func notFound(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, newProblem(CodeRouteNotFound, ""))
+}
+
+// methodNotAllowed также выставляет Allow: методы маршрутов router,
+// подходящих к пути запроса (RFC 9110, 15.5.6).
+func methodNotAllowed(router *mux.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var allow []string
+		router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+			// путь подходит, если маршрут совпал или не совпал только метод
+			var match mux.RouteMatch
+			if !route.Match(r, &match) && !errors.Is(match.MatchErr, mux.ErrMethodMismatch) {
+				return nil
+			}
+			methods, _ := route.GetMethods()
+			for _, m := range methods {
+				if !slices.Contains(allow, m) {
+					allow = append(allow, m)
+				}
+			}
+			return nil
+		})
+		w.Header().Set("Allow", strings.Join(allow, ", "))
+		writeProblem(w, r, newProblem(CodeMethodNotAllowed, r.Method+" "+r.URL.Path))
+	}
+}
@@ -0,0 +1,279 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lab8/i18n"
+	"lab8/store"
+	"lab8/validate"
+)
+
+// Тестирование тел ошибок в формате application/problem+json
+func TestProblemResponses(t *testing.T) {
+	r := newTestRouter()
+
+	tests := []struct {
+		method, target, body string
+		status               int
+		code                 Code
+		params               []InvalidParam
+	}{
+		{"GET", "/users?limit=abc", "", http.StatusBadRequest, CodeInvalidParameter,
+			[]InvalidParam{{Name: "limit", Reason: "Неверное значение limit"}}},
+		{"GET", "/users?limit=101", "", http.StatusBadRequest, CodeInvalidParameter,
+			[]InvalidParam{{Name: "limit", Reason: "Максимальное значение - 100"}}},
+		{"GET", "/users?limit=100&page=9223372036854775807", "", http.StatusBadRequest, CodeInvalidParameter,
+			[]InvalidParam{{Name: "page", Reason: "Слишком большой номер страницы"}}},
+		{"POST", "/users", `{"name":" "}`, http.StatusBadRequest, CodeValidationFailed,
+			[]InvalidParam{{Name: "name", Reason: "Имя не может быть пустым"}}},
+		{"POST", "/users", `{"name":`, http.StatusBadRequest, CodeInvalidBody, nil},
+		{"GET", "/users/99", "", http.StatusNotFound, CodeUserNotFound, nil},
+		{"PATCH", "/users/1", `{}`, http.StatusUnsupportedMediaType, CodeUnsupportedMediaType, nil},
+		{"GET", "/groups", "", http.StatusNotFound, CodeRouteNotFound, nil},
+		{"POST", "/users/1", "", http.StatusMethodNotAllowed, CodeMethodNotAllowed, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.method == "POST" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+
+			var problem Problem
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+			assert.Equal(t, tt.code, problem.Code)
+			assert.Equal(t, "urn:usersvc:problem:"+string(tt.code), problem.Type)
+			assert.Equal(t, tt.status, problem.Status)
+			assert.NotEmpty(t, problem.Title)
+			assert.Equal(t, req.URL.Path, problem.Instance)
+			assert.Equal(t, tt.params, problem.InvalidParams)
+			if tt.status == http.StatusMethodNotAllowed {
+				assert.Equal(t, "GET, PUT, PATCH, DELETE", rr.Header().Get("Allow"))
+			}
+		})
+	}
+}
+
+// Тестирование языка ошибок по Accept-Language
+func TestProblemLanguage(t *testing.T) {
+	r := newTestRouter()
+
+	tests := []struct {
+		acceptLanguage string
+		lang           string
+		title, reason  string
+	}{
+		{"", "ru", "Неверный параметр запроса", "Максимальная длина - 100 символов"},
+		{"en-US,en;q=0.9", "en", "Invalid query parameter", "Maximum length is 100 characters"},
+		{"de", "ru", "Неверный параметр запроса", "Максимальная длина - 100 символов"},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest("GET", "/users?q="+strings.Repeat("a", maxSearchLen+1), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept-Language", tt.acceptLanguage)
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, tt.lang, rr.Header().Get("Content-Language"))
+
+		var problem Problem
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+		assert.Equal(t, tt.title, problem.Title)
+		require.Len(t, problem.InvalidParams, 1)
+		assert.Equal(t, tt.reason, problem.InvalidParams[0].Reason)
+	}
+}
+
+// Тестирование перевода ошибок разбора sort, fields, filter и JSON Patch
+func TestParseErrorLanguage(t *testing.T) {
+	r := newTestRouter()
+
+	tests := []struct {
+		method, target, body string
+		ru, en               string
+	}{
+		{"GET", "/users?sort=password", "", `Сортировка по полю "password" недоступна`, `Sorting by field "password" is not available`},
+		{"GET", "/users?fields=name,password", "", `Неизвестное поле "password"`, `Unknown field "password"`},
+		{"GET", "/users?filter=age==old", "", `Поле "age": "old" не целое число`, `Field "age": "old" is not an integer`},
+		{"GET", "/users?filter=name=='Bob", "", "Позиция 6: незакрытая кавычка", "Position 6: unclosed quote"},
+		{"PATCH", "/users/1", `[{"op":"test","path":"/name","value":"Bob"}]`, "Операция 0 (test /name): значение не совпадает", "Operation 0 (test /name): value does not match"},
+		{"PATCH", "/users/1", `[{"op":"remove","path":"/nickname"}]`, `Операция 0 (remove /nickname): нет поля "nickname"`, `Operation 0 (remove /nickname): field "nickname" does not exist`},
+	}
+	for _, tt := range tests {
+		for lang, want := range map[string]string{"ru": tt.ru, "en": tt.en} {
+			req, err := http.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept-Language", lang)
+			if tt.method == "PATCH" {
+				req.Header.Set("Content-Type", "application/json-patch+json")
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			var problem Problem
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem), tt.target)
+			reason := problem.Detail
+			if len(problem.InvalidParams) == 1 {
+				reason = problem.InvalidParams[0].Reason
+			}
+			assert.Equal(t, want, reason, tt.target)
+		}
+	}
+}
+
+// Тестирование отчета сразу обо всех ошибках в полях
+func TestValidationReport(t *testing.T) {
+	noAdmins := func(u store.User, sc validate.Scenario) []validate.Violation {
+		if strings.EqualFold(u.Name, "admin") {
+			return []validate.Violation{{Field: "name", Rule: "reserved", Message: "Имя зарезервировано"}}
+		}
+		return nil
+	}
+	r := newTestRouterWith(Options{Validators: []UserValidator{noAdmins}})
+
+	tests := []struct {
+		method, target, body string
+		params               []InvalidParam
+	}{
+		{"POST", "/users", `{"id":"7","name":"R2D2","age":200,"email":"r2d2"}`, []InvalidParam{
+			{Name: "id", Reason: "Поле заполняет сервер, передавать его нельзя"},
+			{Name: "name", Reason: "Имя должно начинаться с буквы и состоять из букв, пробелов, дефисов, апострофов и точек"},
+			{Name: "age", Reason: "Значение не больше 150"},
+			{Name: "email", Reason: "Неправильный адрес электронной почты"},
+		}},
+		{"POST", "/users", `{"name":"Admin"}`, []InvalidParam{
+			{Name: "name", Reason: "Имя зарезервировано"},
+		}},
+		{"PUT", "/users/1", `{"name":"` + strings.Repeat("a", 101) + `","age":-1}`, []InvalidParam{
+			{Name: "name", Reason: "Максимальная длина - 100 символов"},
+			{Name: "age", Reason: "Значение не меньше 0"},
+		}},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, tt.body)
+
+		var problem Problem
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+		assert.Equal(t, CodeValidationFailed, problem.Code)
+		assert.Equal(t, tt.params, problem.InvalidParams)
+	}
+
+	// при обновлении id в теле не запрещен
+	req, err := http.NewRequest("PUT", "/users/1", strings.NewReader(`{"id":"1","name":"Alice","email":"alice@example.com"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+// Тестирование своих правил для тегов validate
+func TestValidationRules(t *testing.T) {
+	anyName := func(v reflect.Value, _ string) bool { return true }
+	r := newTestRouterWith(Options{Rules: map[string]validate.Func{"personname": anyName}})
+
+	req, err := http.NewRequest("POST", "/users", strings.NewReader(`{"name":"R2D2","age":30}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	// правило заменено только у этого сервера
+	req, err = http.NewRequest("POST", "/users", strings.NewReader(`{"name":"R2D2","age":30}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	newTestRouterWith(Options{}).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+// Тестирование заголовков для всех кодов ошибок на всех языках
+func TestProblemTitles(t *testing.T) {
+	for code := range statuses {
+		for _, b := range []*i18n.Bundle{ru, en} {
+			assert.Contains(t, b.Messages, "problem."+string(code), b.Lang)
+		}
+	}
+}
+
+// unavailableStore отвечает так же, как MongoStore без связи с базой.
+type unavailableStore struct {
+	store.UserStore
+	retryAfter time.Duration
+}
+
+func (s unavailableStore) Get(ctx context.Context, id string) (store.User, error) {
+	return store.User{}, &store.UnavailableError{RetryAfter: s.retryAfter, Err: errors.New("connection refused")}
+}
+
+// Тестирование ответа 503 с Retry-After при недоступной базе
+func TestStoreUnavailable(t *testing.T) {
+	tests := []struct {
+		retryAfter time.Duration
+		header     string
+	}{
+		{10 * time.Second, "10"},
+		{1500 * time.Millisecond, "2"},
+		{0, "5"},
+	}
+	for _, tt := range tests {
+		s := unavailableStore{store.NewMemoryStore(nil), tt.retryAfter}
+		req, err := http.NewRequest("GET", "/users/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		New(s, Options{}).Router().ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.Equal(t, tt.header, rr.Header().Get("Retry-After"))
+
+		var problem Problem
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+		assert.Equal(t, CodeUnavailable, problem.Code)
+		assert.Empty(t, problem.Detail)
+	}
+}
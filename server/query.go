@@ -0,0 +1,100 @@
+package server
+
+import (
+	"slices"
+	"strings"
+
+	"lab8/store"
+)
+
+// paramError - ошибка разбора параметра запроса: key - ключ сообщения
+// в messages, args - его параметры.
+type paramError struct {
+	key  string
+	args []any
+}
+
+func (e *paramError) Error() string {
+	return messages.Match("").T(e.key, e.args...)
+}
+
+// parseSort разбирает параметр sort вида "name,-age,created_at".
+// Минус перед полем означает сортировку по убыванию.
+func parseSort(param string) ([]store.SortField, error) {
+	if param == "" {
+		return nil, nil
+	}
+	var sort []store.SortField
+	for _, part := range strings.Split(param, ",") {
+		sf := store.SortField{Field: strings.TrimSpace(part)}
+		if name, ok := strings.CutPrefix(sf.Field, "-"); ok {
+			sf.Field, sf.Desc = name, true
+		}
+		if !slices.Contains(store.SortableFields, sf.Field) {
+			return nil, &paramError{key: "sort.unknown_field", args: []any{sf.Field}}
+		}
+		if slices.ContainsFunc(sort, func(prev store.SortField) bool { return prev.Field == sf.Field }) {
+			return nil, &paramError{key: "sort.duplicate", args: []any{sf.Field}}
+		}
+		sort = append(sort, sf)
+	}
+	return sort, nil
+}
+
+// formatSort возвращает каноническую запись сортировки, к которой привязан курсор.
+func formatSort(sort []store.SortField) string {
+	parts := make([]string, len(sort))
+	for i, sf := range sort {
+		parts[i] = sf.Field
+		if sf.Desc {
+			parts[i] = "-" + sf.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseFields разбирает параметр fields вида "id,name".
+func parseFields(param string) ([]string, error) {
+	if param == "" {
+		return nil, nil
+	}
+	var fields []string
+	for _, field := range strings.Split(param, ",") {
+		field = strings.TrimSpace(field)
+		if !slices.Contains(store.Fields, field) {
+			return nil, &paramError{key: "fields.unknown", args: []any{field}}
+		}
+		if !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// project оставляет у пользователей только поля fields.
+// Без fields пользователи отдаются целиком.
+func project(users []store.User, fields []string) any {
+	if len(fields) == 0 {
+		return users
+	}
+	result := make([]map[string]any, len(users))
+	for i, u := range users {
+		m := make(map[string]any, len(fields))
+		for _, field := range fields {
+			switch field {
+			case "id":
+				m[field] = u.ID
+			case "name":
+				m[field] = u.Name
+			case "age":
+				m[field] = u.Age
+			case "email":
+				m[field] = u.Email
+			case "created_at":
+				m[field] = u.CreatedAt
+			}
+		}
+		result[i] = m
+	}
+	return result
+}
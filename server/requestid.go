@@ -0,0 +1,21 @@
+package server
+
+import (
+	"net/http"
+
+	"lab8/requestid"
+)
+
+// requestID берет X-Request-ID из запроса или создает новый, если его нет
+// или он не прошел requestid.Valid, кладет его в контекст запроса и
+// возвращает клиенту в том же заголовке.
+func requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+	})
+}
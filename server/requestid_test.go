@@ -0,0 +1,55 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lab8/requestid"
+)
+
+// Тестирование X-Request-ID: принимается от клиента или создается,
+// возвращается в заголовке и в теле ошибки
+func TestRequestID(t *testing.T) {
+	r := newTestRouter()
+
+	tests := []struct {
+		name, header string
+		keep         bool
+	}{
+		{"от клиента", "req-42", true},
+		{"нет заголовка", "", false},
+		{"недопустимые символы", "a b\nc", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, target := range []string{"/users/99", "/groups"} {
+				req, err := http.NewRequest("GET", target, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tt.header != "" {
+					req.Header.Set("X-Request-ID", tt.header)
+				}
+				rr := httptest.NewRecorder()
+				r.ServeHTTP(rr, req)
+
+				id := rr.Header().Get("X-Request-ID")
+				if tt.keep {
+					assert.Equal(t, tt.header, id)
+				} else {
+					assert.True(t, requestid.Valid(id))
+					assert.NotEqual(t, tt.header, id)
+				}
+
+				var problem Problem
+				require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+				assert.Equal(t, id, problem.RequestID)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+// responseWriter запоминает код ответа и размер тела и не дает
+// обработчику записать второй ответ поверх уже отправленного.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+	done   bool
+	// dropped - коды повторных ответов, которые respond отбросил.
+	dropped []int
+}
+
+// singleResponse оборачивает ResponseWriter каждого запроса в responseWriter
+// и пишет в журнал отброшенные повторные ответы с контекстом запроса.
+func singleResponse(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{ResponseWriter: w}
+		next.ServeHTTP(rw, r)
+		for _, code := range rw.dropped {
+			slog.WarnContext(r.Context(), "Повторный ответ отброшен", "status", code, "sent", rw.status)
+		}
+	})
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap нужен http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// unwrap снимает с w все обертки и возвращает writer net/http.
+func unwrap(w http.ResponseWriter) http.ResponseWriter {
+	for {
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return w
+		}
+		w = u.Unwrap()
+	}
+}
+
+// respond отправляет ответ целиком: код и тело v в JSON, при v == nil - без тела.
+// Content-Type, если не задан, - application/json.
+// Все ответы обработчиков идут через него; повторный вызов для того же
+// запроса ничего не пишет.
+func respond(w http.ResponseWriter, code int, v any) {
+	if rw, ok := w.(*responseWriter); ok {
+		if rw.done {
+			rw.dropped = append(rw.dropped, code)
+			return
+		}
+		rw.done = true
+	}
+	if v == nil {
+		w.WriteHeader(code)
+		return
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lab8/requestid"
+)
+
+// Тестирование того, что второй ответ не дописывается к первому
+func TestRespondOnce(t *testing.T) {
+	rr := httptest.NewRecorder()
+	w := &responseWriter{ResponseWriter: rr}
+
+	respond(w, http.StatusNotFound, map[string]string{"error": "Пользователь не найден"})
+	respond(w, http.StatusOK, map[string]string{"message": "Пользователь обновлен"})
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.JSONEq(t, `{"error":"Пользователь не найден"}`, rr.Body.String())
+}
+
+// Тестирование записи об отброшенном ответе: с request_id запроса
+func TestRespondOnceLog(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(requestid.NewHandler(slog.NewJSONHandler(&buf, nil))))
+
+	h := requestID(singleResponse(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		respond(w, http.StatusCreated, nil)
+		respond(w, http.StatusInternalServerError, nil)
+	})))
+	req := httptest.NewRequest("POST", "/users", nil)
+	req.Header.Set(requestid.Header, "req-7")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	records := logRecords(t, &buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, "WARN", records[0]["level"])
+	assert.Equal(t, 500.0, records[0]["status"])
+	assert.Equal(t, 201.0, records[0]["sent"])
+	assert.Equal(t, "req-7", records[0]["request_id"])
+}
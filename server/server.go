@@ -0,0 +1,435 @@
+// Package server содержит HTTP-обработчики /users, работающие с любым store.UserStore.
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+
+	"lab8/requestid"
+	"lab8/rsql"
+	"lab8/store"
+	"lab8/validate"
+)
+
+// Options - настройки обработчиков. Нулевые значения заменяются значениями по умолчанию.
+type Options struct {
+	// Timeout - время на операцию с хранилищем, по умолчанию 10 секунд.
+	Timeout time.Duration
+	// RouteTimeouts - время на операцию для отдельных маршрутов (RouteList, RoutePatch...)
+	// вместо Timeout.
+	RouteTimeouts map[string]time.Duration
+	// ListEnvelope - отдавать GET /users объектом с items и total вместо массива.
+	ListEnvelope bool
+	// CursorSecret - ключ подписи курсоров. Если пуст, создается случайный,
+	// и выданные курсоры перестают действовать после перезапуска.
+	CursorSecret []byte
+	// Validators - дополнительные проверки пользователя сверх тегов validate
+	// в store.User; их нарушения попадают в тот же ответ validation_failed.
+	Validators []UserValidator
+	// Rules - свои правила для тегов validate в store.User; правило
+	// со встроенным именем заменяет встроенное.
+	Rules map[string]validate.Func
+	// MaxBodyBytes - максимальный размер тела запроса, по умолчанию 1 МиБ.
+	MaxBodyBytes int64
+	// Version и StoreType показываются в /healthz и /readyz.
+	Version   string
+	StoreType string
+	// Checks - проверки зависимостей для /readyz.
+	Checks []HealthCheck
+	// AccessLog - журнал запросов, по умолчанию выключен.
+	AccessLog AccessLog
+}
+
+const (
+	// maxSearchLen - максимальная длина параметров поиска по имени.
+	maxSearchLen = 100
+	// maxFilterLen - максимальная длина параметра filter.
+	maxFilterLen = 1000
+	// maxLimit - максимальный размер страницы.
+	maxLimit = 100
+)
+
+type Server struct {
+	store   store.UserStore
+	opts    Options
+	cursors cursorCodec
+	// validator проверяет store.User по тегам validate.
+	validator *validate.Validator
+	// started - время создания сервера для uptime.
+	started time.Time
+	// draining включается Drain при остановке.
+	draining atomic.Bool
+}
+
+func New(s store.UserStore, opts Options) *Server {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.AccessLog.Logger != nil {
+		// request_id берется из контекста записи
+		opts.AccessLog.Logger = slog.New(requestid.NewHandler(opts.AccessLog.Logger.Handler()))
+	}
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = defaultMaxBodyBytes
+	}
+	if len(opts.CursorSecret) == 0 {
+		opts.CursorSecret = make([]byte, 32)
+		rand.Read(opts.CursorSecret)
+	}
+	validator := validate.New()
+	for name, fn := range opts.Rules {
+		validator.Register(name, fn)
+	}
+	return &Server{
+		store:     s,
+		opts:      opts,
+		cursors:   cursorCodec{secret: opts.CursorSecret},
+		validator: validator,
+		started:   time.Now(),
+	}
+}
+
+// Router возвращает роутер со всеми маршрутами /users, /healthz и /readyz.
+func (s *Server) Router() *mux.Router {
+	r := mux.NewRouter()
+	r.HandleFunc("/users", s.getUsers).Methods("GET").Name(RouteList)
+	r.HandleFunc("/users/{id}", s.getUser).Methods("GET").Name(RouteGet)
+	r.HandleFunc("/users", s.createUser).Methods("POST").Name(RouteCreate)
+	r.HandleFunc("/users/{id}", s.updateUser).Methods("PUT").Name(RouteUpdate)
+	r.HandleFunc("/users/{id}", s.patchUser).Methods("PATCH").Name(RoutePatch)
+	r.HandleFunc("/users/{id}", s.deleteUser).Methods("DELETE").Name(RouteDelete)
+	r.HandleFunc("/healthz", s.healthz).Methods("GET").Name(RouteHealth)
+	r.HandleFunc("/readyz", s.readyz).Methods("GET").Name(RouteReady)
+	r.NotFoundHandler = s.unmatched(notFound)
+	r.MethodNotAllowedHandler = s.unmatched(methodNotAllowed(r))
+	r.Use(requestID, singleResponse, s.logRequests, countCancelled, localize)
+	return r
+}
+
+// unmatched оборачивает ответ на запрос мимо маршрутов в те же middleware,
+// что и r.Use: mux применяет их только к найденным маршрутам.
+func (s *Server) unmatched(h http.HandlerFunc) http.Handler {
+	return requestID(singleResponse(s.logRequests(countCancelled(localize(h)))))
+}
+
+// userID достает {id} из пути и проверяет его формат в хранилище.
+// При неверном id отвечает 400 и возвращает false.
+func (s *Server) userID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := mux.Vars(r)["id"]
+	if err := s.store.ValidateID(id); err != nil {
+		writeProblem(w, r, newProblem(CodeInvalidID, ""))
+		return "", false
+	}
+	return id, true
+}
+
+func (s *Server) getUsers(w http.ResponseWriter, r *http.Request) {
+
+	minAgeParam := r.URL.Query().Get("min_age")
+	maxAgeParam := r.URL.Query().Get("max_age")
+	limitParam := r.URL.Query().Get("limit")
+	pageParam := r.URL.Query().Get("page")
+
+	var minAge, maxAge *int
+	limit, page := 10, 1
+	var err error
+
+	// keyset-пагинация включается параметром cursor, первая страница - cursor=
+	byCursor := r.URL.Query().Has("cursor")
+	if byCursor && pageParam != "" {
+		invalidParameter(w, r, "page", tr(r).T("param.page_cursor"))
+		return
+	}
+
+	if limitParam != "" {
+		limit, err = strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 {
+			invalidParameter(w, r, "limit", tr(r).T("param.invalid", "limit"))
+			return
+		}
+		if limit > maxLimit {
+			invalidParameter(w, r, "limit", tr(r).T("param.max", maxLimit))
+			return
+		}
+	}
+
+	if pageParam != "" {
+		page, err = strconv.Atoi(pageParam)
+		if err != nil || page <= 0 {
+			invalidParameter(w, r, "page", tr(r).T("param.invalid", "page"))
+			return
+		}
+		// смещение (page - 1) * limit не должно переполняться
+		if page-1 > math.MaxInt/limit {
+			invalidParameter(w, r, "page", tr(r).T("param.page_too_large"))
+			return
+		}
+	}
+
+	sort, err := parseSort(r.URL.Query().Get("sort"))
+	if err != nil {
+		invalidParameter(w, r, "sort", errorReason(tr(r), err))
+		return
+	}
+
+	fields, err := parseFields(r.URL.Query().Get("fields"))
+	if err != nil {
+		invalidParameter(w, r, "fields", errorReason(tr(r), err))
+		return
+	}
+
+	search := map[string]string{}
+	for _, param := range []string{"name", "name_prefix", "name_contains", "q"} {
+		value := r.URL.Query().Get(param)
+		if !utf8.ValidString(value) {
+			invalidParameter(w, r, param, tr(r).T("param.invalid", param))
+			return
+		}
+		if utf8.RuneCountInString(value) > maxSearchLen {
+			invalidParameter(w, r, param, tr(r).N("param.too_long", maxSearchLen))
+			return
+		}
+		search[param] = value
+	}
+
+	var expr rsql.Node
+	if param := r.URL.Query().Get("filter"); param != "" {
+		if len(param) > maxFilterLen {
+			invalidParameter(w, r, "filter", tr(r).N("param.too_long", maxFilterLen))
+			return
+		}
+		if !utf8.ValidString(param) {
+			invalidParameter(w, r, "filter", tr(r).T("param.invalid", "filter"))
+			return
+		}
+		expr, err = rsql.Parse(param)
+		if err == nil {
+			err = rsql.Bind(expr, store.FilterSchema)
+		}
+		if err != nil {
+			invalidParameter(w, r, "filter", errorReason(tr(r), err))
+			return
+		}
+	}
+
+	if minAgeParam != "" {
+		age, err := strconv.Atoi(minAgeParam)
+		if err != nil || age < 0 {
+			invalidParameter(w, r, "min_age", tr(r).T("param.invalid", "min_age"))
+			return
+		}
+		minAge = &age
+	}
+
+	if maxAgeParam != "" {
+		age, err := strconv.Atoi(maxAgeParam)
+		if err != nil || age < 0 || minAge != nil && age < *minAge {
+			invalidParameter(w, r, "max_age", tr(r).T("param.invalid", "max_age"))
+			return
+		}
+		maxAge = &age
+	}
+
+	ctx, cancel := s.requestContext(r)
+	defer cancel()
+
+	filter := store.Filter{
+		Name:         search["name"],
+		NamePrefix:   search["name_prefix"],
+		NameContains: search["name_contains"],
+		Query:        search["q"],
+		MinAge:       minAge,
+		MaxAge:       maxAge,
+		Expr:         expr,
+		Sort:         sort,
+		Fields:       fields,
+		Limit:        limit,
+		//смещение
+		Skip: (page - 1) * limit,
+	}
+
+	if byCursor {
+		s.listByCursor(ctx, w, r, filter)
+		return
+	}
+
+	users, err := s.store.List(ctx, filter)
+	if err != nil {
+		writeProblem(w, r, storeProblem(ctx, err, tr(r).T("db.read")))
+		return
+	}
+
+	total, err := s.store.Count(ctx, filter)
+	if err != nil {
+		writeProblem(w, r, storeProblem(ctx, err, tr(r).T("db.count")))
+		return
+	}
+
+	result := newUserPage(project(users, fields), total, page, limit)
+	result.writeHeaders(w, r)
+	if s.opts.ListEnvelope {
+		respond(w, http.StatusOK, result)
+		return
+	}
+	respond(w, http.StatusOK, result.Items)
+}
+
+// listByCursor отдает страницу после курсора из параметра cursor
+// и next_cursor, если за ней есть еще пользователи.
+func (s *Server) listByCursor(ctx context.Context, w http.ResponseWriter, r *http.Request, filter store.Filter) {
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		after, err := s.cursors.decode(token, filter.Sort)
+		if err != nil {
+			invalidParameter(w, r, "cursor", tr(r).T("param.invalid", "cursor"))
+			return
+		}
+		filter.After = &after
+	}
+
+	// берем на одного больше, чтобы узнать, есть ли следующая страница
+	limit := filter.Limit
+	filter.Limit++
+	filter.Skip = 0
+
+	users, err := s.store.List(ctx, filter)
+	if err != nil {
+		writeProblem(w, r, storeProblem(ctx, err, tr(r).T("db.read")))
+		return
+	}
+
+	var next *store.Cursor
+	if len(users) > limit {
+		users = users[:limit]
+		after := store.CursorAfter(users[limit-1], filter.Sort)
+		next = &after
+	}
+
+	result := cursorPage{Items: project(users, filter.Fields)}
+	if next != nil {
+		result.NextCursor = s.cursors.encode(*next, filter.Sort)
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, result.nextURL(r.URL)))
+	}
+	respond(w, http.StatusOK, result)
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.userID(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := s.requestContext(r)
+	defer cancel()
+
+	user, err := s.store.Get(ctx, id)
+	if err != nil {
+		writeProblem(w, r, storeProblem(ctx, err, tr(r).T("db.read")))
+		return
+	}
+
+	w.Header().Set("ETag", etag(user.Version))
+	if notModified(r, user.Version) {
+		respond(w, http.StatusNotModified, nil)
+		return
+	}
+	respond(w, http.StatusOK, user)
+}
+
+func (s *Server) createUser(w http.ResponseWriter, r *http.Request) {
+	var newUser store.User
+	if !s.decodeJSON(w, r, &newUser) {
+		return
+	}
+
+	if params := s.validateUser(tr(r), newUser, validate.Create); params != nil {
+		writeProblem(w, r, newProblem(CodeValidationFailed, "", params...))
+		return
+	}
+
+	ctx, cancel := s.requestContext(r)
+	defer cancel()
+
+	newUser, err := s.store.Create(ctx, newUser)
+	if err != nil {
+		writeProblem(w, r, storeProblem(ctx, err, tr(r).T("db.create")))
+		return
+	}
+
+	w.Header().Set("ETag", etag(newUser.Version))
+	w.Header().Set("Location", userURL(newUser.ID))
+	respond(w, http.StatusCreated, newUser)
+}
+
+func (s *Server) updateUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.userID(w, r)
+	if !ok {
+		return
+	}
+
+	var updatedUser store.User
+	if !s.decodeJSON(w, r, &updatedUser) {
+		return
+	}
+
+	if params := s.validateUser(tr(r), updatedUser, validate.Update); params != nil {
+		writeProblem(w, r, newProblem(CodeValidationFailed, "", params...))
+		return
+	}
+
+	ctx, cancel := s.requestContext(r)
+	defer cancel()
+
+	version, err := parseIfMatch(r).version(ctx, s.store, id)
+	if err != nil {
+		writeProblem(w, r, storeProblem(ctx, err, tr(r).T("db.update")))
+		return
+	}
+
+	updatedUser, err = s.store.Update(ctx, id, updatedUser, version)
+	if err != nil {
+		writeProblem(w, r, storeProblem(ctx, err, tr(r).T("db.update")))
+		return
+	}
+
+	w.Header().Set("ETag", etag(updatedUser.Version))
+	respond(w, http.StatusOK, map[string]string{"message": tr(r).T("user.updated")})
+}
+
+func (s *Server) deleteUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.userID(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := s.requestContext(r)
+	defer cancel()
+
+	version, err := parseIfMatch(r).version(ctx, s.store, id)
+	if err != nil {
+		writeProblem(w, r, storeProblem(ctx, err, tr(r).T("db.delete")))
+		return
+	}
+
+	if err := s.store.Delete(ctx, id, version); err != nil {
+		writeProblem(w, r, storeProblem(ctx, err, tr(r).T("db.delete")))
+		return
+	}
+
+	respond(w, http.StatusNoContent, nil)
+}
+
+// userURL возвращает путь ресурса пользователя для заголовка Location.
+func userURL(id string) string {
+	return "/users/" + url.PathEscape(id)
+}
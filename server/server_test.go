@@ -0,0 +1,596 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"lab8/store"
+)
+
+func newTestRouter() http.Handler {
+	return newTestRouterWith(Options{})
+}
+
+func newTestRouterWith(opts Options) http.Handler {
+	s := store.NewMemoryStore(nil,
+		store.User{ID: "1", Name: "Alice", Age: 25},
+		store.User{ID: "2", Name: "Bob", Age: 30},
+	)
+	return New(s, opts).Router()
+}
+
+// Тестирование GET /users
+func TestGetUsers(t *testing.T) {
+	r := newTestRouter()
+
+	req, err := http.NewRequest("GET", "/users", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var users []store.User
+	err = json.NewDecoder(rr.Body).Decode(&users)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, len(users))
+	assert.Equal(t, "Alice", users[0].Name)
+	assert.Equal(t, "Bob", users[1].Name)
+}
+
+// Тестирование фильтрации по имени в GET /users
+func TestGetUsersWithFilter(t *testing.T) {
+	r := newTestRouter()
+
+	req, err := http.NewRequest("GET", "/users?name=alice", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var users []store.User
+	err = json.NewDecoder(rr.Body).Decode(&users)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, len(users))
+	assert.Equal(t, "Alice", users[0].Name)
+}
+
+// Тестирование параметра filter в GET /users
+func TestGetUsersWithRSQLFilter(t *testing.T) {
+	r := newTestRouter()
+
+	req, err := http.NewRequest("GET", "/users?filter="+url.QueryEscape("age>=18;name=like=al*,name==Bob"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var users []store.User
+	err = json.NewDecoder(rr.Body).Decode(&users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(users))
+
+	for _, filter := range []string{"age>=", "password==1", "age==old", "name=like=*a*a*a*b", "name=like=\x98"} {
+		req, err := http.NewRequest("GET", "/users?filter="+url.QueryEscape(filter), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, filter)
+	}
+}
+
+// Тестирование ограничения длины поиска в GET /users
+func TestGetUsersSearchTooLong(t *testing.T) {
+	r := newTestRouter()
+
+	req, err := http.NewRequest("GET", "/users?name_contains="+strings.Repeat("a", maxSearchLen+1), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+// Тестирование поиска с невалидным UTF-8 в GET /users
+func TestGetUsersSearchInvalidUTF8(t *testing.T) {
+	r := newTestRouter()
+
+	for _, param := range []string{"name", "name_prefix", "name_contains", "q"} {
+		req, err := http.NewRequest("GET", "/users?"+param+"=%98", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, param)
+	}
+}
+
+// Тестирование фильтрации по возрасту в GET /users
+func TestGetUsersWithAgeFilter(t *testing.T) {
+	r := newTestRouter()
+
+	req, err := http.NewRequest("GET", "/users?min_age=26&max_age=30", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var users []store.User
+	err = json.NewDecoder(rr.Body).Decode(&users)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, len(users))
+	assert.Equal(t, "Bob", users[0].Name)
+
+	// max_age=0 - граница, а не отсутствие фильтра
+	req, err = http.NewRequest("GET", "/users?max_age=0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	users = nil
+	err = json.NewDecoder(rr.Body).Decode(&users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, users)
+	assert.Equal(t, "0", rr.Header().Get("X-Total-Count"))
+
+	req, err = http.NewRequest("GET", "/users?min_age=30&max_age=20", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+// Тестирование пагинации в GET /users
+func TestGetUsersWithPagination(t *testing.T) {
+	r := newTestRouter()
+
+	limit := 1
+	page := 2
+	req, err := http.NewRequest("GET", "/users?limit="+strconv.Itoa(limit)+"&page="+strconv.Itoa(page), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var users []store.User
+	err = json.NewDecoder(rr.Body).Decode(&users)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, limit, len(users))
+	assert.Equal(t, "Bob", users[0].Name)
+	assert.Equal(t, "2", rr.Header().Get("X-Total-Count"))
+	assert.Equal(t, `</users?limit=1&page=1>; rel="first", </users?limit=1&page=1>; rel="prev", </users?limit=1&page=2>; rel="last"`, rr.Header().Get("Link"))
+}
+
+// Тестирование ответа-обертки в GET /users
+func TestGetUsersEnvelope(t *testing.T) {
+	r := newTestRouterWith(Options{ListEnvelope: true})
+
+	req, err := http.NewRequest("GET", "/users?limit=1&name_contains=b", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var page struct {
+		Items      []store.User `json:"items"`
+		Total      int64        `json:"total"`
+		Page       int          `json:"page"`
+		TotalPages int          `json:"total_pages"`
+	}
+	err = json.NewDecoder(rr.Body).Decode(&page)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, int64(1), page.Total)
+	assert.Equal(t, 1, page.Page)
+	assert.Equal(t, 1, page.TotalPages)
+	assert.Equal(t, "Bob", page.Items[0].Name)
+	assert.Equal(t, `</users?limit=1&name_contains=b&page=1>; rel="first", </users?limit=1&name_contains=b&page=1>; rel="last"`, rr.Header().Get("Link"))
+}
+
+// Тестирование keyset-пагинации в GET /users
+func TestGetUsersWithCursor(t *testing.T) {
+	r := newTestRouter()
+
+	var names []string
+	next := "/users?cursor=&limit=1"
+	for next != "" {
+		req, err := http.NewRequest("GET", next, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var page struct {
+			Items      []store.User `json:"items"`
+			NextCursor string       `json:"next_cursor"`
+		}
+		err = json.NewDecoder(rr.Body).Decode(&page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, u := range page.Items {
+			names = append(names, u.Name)
+		}
+
+		next = ""
+		if page.NextCursor != "" {
+			next = "/users?cursor=" + page.NextCursor + "&limit=1"
+			assert.Equal(t, "<"+next+">; rel=\"next\"", rr.Header().Get("Link"))
+		}
+	}
+	assert.Equal(t, []string{"Alice", "Bob"}, names)
+
+	for _, target := range []string{"/users?cursor=abc.def", "/users?cursor=&page=2"} {
+		req, err := http.NewRequest("GET", target, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, target)
+	}
+}
+
+// Тестирование сортировки и выбора полей в GET /users
+func TestGetUsersSortAndFields(t *testing.T) {
+	r := newTestRouter()
+
+	req, err := http.NewRequest("GET", "/users?sort=-age,name&fields=name", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[{"name":"Bob"},{"name":"Alice"}]`, rr.Body.String())
+
+	for _, target := range []string{"/users?sort=password", "/users?sort=age,-age", "/users?fields=id,password"} {
+		req, err := http.NewRequest("GET", target, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, target)
+	}
+}
+
+// Тестирование курсора, выданного для другой сортировки
+func TestGetUsersCursorSortMismatch(t *testing.T) {
+	r := newTestRouter()
+
+	req, err := http.NewRequest("GET", "/users?sort=-created_at&cursor=&limit=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var page struct {
+		NextCursor string `json:"next_cursor"`
+	}
+	err = json.NewDecoder(rr.Body).Decode(&page)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err = http.NewRequest("GET", "/users?sort=-created_at&limit=1&cursor="+page.NextCursor, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req, err = http.NewRequest("GET", "/users?sort=name&limit=1&cursor="+page.NextCursor, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+// Тестирование POST /users
+func TestCreateUser(t *testing.T) {
+	r := newTestRouter()
+
+	user := `{"name":"AAAA"}`
+	req, err := http.NewRequest("POST", "/users", strings.NewReader(user))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	var createdUser store.User
+	err = json.NewDecoder(rr.Body).Decode(&createdUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "AAAA", createdUser.Name)
+	assert.NotEmpty(t, createdUser.ID)
+	assert.Equal(t, "/users/"+createdUser.ID, rr.Header().Get("Location"))
+}
+
+// Тестирование POST /users с пустым именем
+func TestCreateUserInvalid(t *testing.T) {
+	r := newTestRouter()
+
+	req, err := http.NewRequest("POST", "/users", strings.NewReader(`{"name":"  "}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+// Тестирование PUT /users/{id}
+func TestUpdateUser(t *testing.T) {
+	r := newTestRouter()
+
+	update := `{"name":"Updated Name"}`
+	req, err := http.NewRequest("PUT", "/users/1", strings.NewReader(update))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response map[string]string
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "Пользователь обновлен", response["message"])
+}
+
+// Тестирование PUT /users/{id} для несуществующего пользователя
+func TestUpdateUserNotFound(t *testing.T) {
+	r := newTestRouter()
+
+	req, err := http.NewRequest("PUT", "/users/99", strings.NewReader(`{"name":"Nobody"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	var problem Problem
+	err = json.NewDecoder(rr.Body).Decode(&problem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, CodeUserNotFound, problem.Code)
+	assert.False(t, json.NewDecoder(rr.Body).More(), "второе тело в ответе")
+}
+
+// Тестирование PATCH /users/{id}
+func TestPatchUser(t *testing.T) {
+	r := newTestRouter()
+
+	cases := []struct {
+		contentType string
+		body        string
+		code        int
+		name        string
+		age         int
+	}{
+		{"application/merge-patch+json", `{"age":26}`, http.StatusOK, "Alice", 26},
+		{"application/json-patch+json", `[{"op":"test","path":"/age","value":26},{"op":"replace","path":"/name","value":"Alicia"}]`, http.StatusOK, "Alicia", 26},
+		{"application/merge-patch+json", `{"age":null}`, http.StatusOK, "Alicia", 0},
+		{"application/json", `{"age":1}`, http.StatusUnsupportedMediaType, "Alicia", 0},
+		{"application/merge-patch+json", `{"name":" "}`, http.StatusBadRequest, "Alicia", 0},
+		{"application/merge-patch+json", `{"nickname":"al"}`, http.StatusUnprocessableEntity, "Alicia", 0},
+		{"application/merge-patch+json", `{"id":"7"}`, http.StatusUnprocessableEntity, "Alicia", 0},
+		{"application/json-patch+json", `[{"op":"test","path":"/age","value":99},{"op":"replace","path":"/name","value":"X"}]`, http.StatusConflict, "Alicia", 0},
+		{"application/json-patch+json", `[{"op":"remove","path":"/nickname"}]`, http.StatusUnprocessableEntity, "Alicia", 0},
+		{"application/json-patch+json", `{"op":"add"}`, http.StatusBadRequest, "Alicia", 0},
+		{"application/json-patch+json", `[{"op":"replace","path":"/age","value":30},{"op":"replace","path":"/email","value":null}]`, http.StatusOK, "Alicia", 30},
+		{"application/json-patch+json", `[{"op":"test","path":"/email","value":""},{"op":"replace","path":"/age","value":null}]`, http.StatusOK, "Alicia", 0},
+	}
+	for _, c := range cases {
+		req, err := http.NewRequest("PATCH", "/users/1", strings.NewReader(c.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", c.contentType)
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, c.code, rr.Code, c.body)
+
+		req, err = http.NewRequest("GET", "/users/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr = httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		var user store.User
+		err = json.NewDecoder(rr.Body).Decode(&user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, c.name, user.Name, c.body)
+		assert.Equal(t, c.age, user.Age, c.body)
+	}
+}
+
+// Тестирование ETag, If-None-Match и If-Match
+func TestUserETags(t *testing.T) {
+	r := newTestRouter()
+
+	do := func(method, target, body string, header http.Header) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, target, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do("POST", "/users", `{"name":"Carol","age":40}`, http.Header{"Content-Type": {"application/json"}})
+	assert.Equal(t, `"1"`, rr.Header().Get("ETag"))
+	var created store.User
+	err := json.NewDecoder(rr.Body).Decode(&created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := "/users/" + created.ID
+
+	rr = do("GET", target, "", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"1"`, rr.Header().Get("ETag"))
+
+	rr = do("GET", target, "", http.Header{"If-None-Match": {`W/"1"`}})
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Empty(t, rr.Body.String())
+
+	rr = do("PUT", target, `{"name":"Carol","age":41}`, http.Header{"If-Match": {`"1"`}, "Content-Type": {"application/json"}})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+
+	rr = do("PUT", target, `{"name":"Carol","age":42}`, http.Header{"If-Match": {`"1"`}, "Content-Type": {"application/json"}})
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+	rr = do("PATCH", target, `{"age":43}`, http.Header{"If-Match": {`"1"`}, "Content-Type": {"application/merge-patch+json"}})
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+	rr = do("PATCH", target, `{"age":43}`, http.Header{"If-Match": {`"5", "2"`}, "Content-Type": {"application/merge-patch+json"}})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
+
+	rr = do("GET", target, "", http.Header{"If-None-Match": {`"2"`}})
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = do("DELETE", target, "", http.Header{"If-Match": {`W/"3"`}})
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+	rr = do("DELETE", target, "", http.Header{"If-Match": {`"2", "3"`}})
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+// Тестирование DELETE /users/{id}
+func TestDeleteUser(t *testing.T) {
+	r := newTestRouter()
+
+	req, err := http.NewRequest("DELETE", "/users/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Empty(t, rr.Body.String())
+
+	req, err = http.NewRequest("DELETE", "/users/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
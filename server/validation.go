@@ -0,0 +1,47 @@
+package server
+
+import (
+	"strconv"
+
+	"lab8/i18n"
+	"lab8/store"
+	"lab8/validate"
+)
+
+// UserValidator - дополнительная проверка пользователя в сценарии sc.
+// Нарушения без Message получают текст по ключу validation.<rule>.
+type UserValidator func(u store.User, sc validate.Scenario) []validate.Violation
+
+// validateUser возвращает все ошибки в полях пользователя на языке l, nil - если их нет.
+func (s *Server) validateUser(l *i18n.Localizer, u store.User, sc validate.Scenario) []InvalidParam {
+	violations := s.validator.Struct(u, sc)
+	for _, check := range s.opts.Validators {
+		violations = append(violations, check(u, sc)...)
+	}
+
+	var params []InvalidParam
+	for _, v := range violations {
+		params = append(params, InvalidParam{Name: v.Field, Reason: violationReason(l, v)})
+	}
+	return params
+}
+
+// violationReason ищет текст сначала для поля (validation.name.required),
+// затем для правила (validation.required). Числовой параметр правила
+// подставляется с формой множественного числа.
+func violationReason(l *i18n.Localizer, v validate.Violation) string {
+	if v.Message != "" {
+		return v.Message
+	}
+	key := "validation." + v.Field + "." + v.Rule
+	if !l.Has(key) {
+		key = "validation." + v.Rule
+	}
+	if n, err := strconv.Atoi(v.Param); err == nil {
+		return l.N(key, n)
+	}
+	if v.Param != "" {
+		return l.T(key, v.Param)
+	}
+	return l.T(key)
+}
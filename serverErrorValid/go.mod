@@ -1,19 +0,0 @@
-module serverErrorValid
-
-go 1.23.2
-
-require go.mongodb.org/mongo-driver v1.17.1
-
-require (
-	github.com/golang/snappy v0.0.4 // indirect
-	github.com/gorilla/mux v1.8.1
-	github.com/klauspost/compress v1.13.6 // indirect
-	github.com/montanaflynn/stats v0.7.1 // indirect
-	github.com/xdg-go/pbkdf2 v1.0.0 // indirect
-	github.com/xdg-go/scram v1.1.2 // indirect
-	github.com/xdg-go/stringprep v1.0.4 // indirect
-	github.com/youmark/pkcs8 v0.0.0-20240726163527-a2c0da244d78 // indirect
-	golang.org/x/crypto v0.26.0 // indirect
-	golang.org/x/sync v0.8.0 // indirect
-	golang.org/x/text v0.17.0 // indirect
-)
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"fmt"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IDCodec переводит id из API (строку) в значение поля _id в Mongo и обратно.
+type IDCodec interface {
+	// Parse проверяет строковый id и возвращает значение _id или ErrInvalidID.
+	Parse(id string) (any, error)
+	// Format возвращает строковый id для значения _id из документа.
+	Format(v any) (string, error)
+	// New создает _id для нового документа.
+	New() any
+}
+
+// NewIDCodec возвращает кодек по типу id: objectid, uuidv7 или ulid.
+func NewIDCodec(kind string) (IDCodec, error) {
+	switch kind {
+	case "", "objectid":
+		return ObjectIDCodec{}, nil
+	case "uuidv7":
+		gen, _ := NewIDGenerator(kind)
+		return StringIDCodec{gen: gen, pattern: uuidPattern}, nil
+	case "ulid":
+		gen, _ := NewIDGenerator(kind)
+		return StringIDCodec{gen: gen, pattern: ulidPattern}, nil
+	}
+	return nil, fmt.Errorf("неизвестный тип id %q", kind)
+}
+
+// ObjectIDCodec хранит id как primitive.ObjectID, в API это 24 hex-символа.
+type ObjectIDCodec struct{}
+
+func (ObjectIDCodec) Parse(id string) (any, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	return objectID, nil
+}
+
+func (ObjectIDCodec) Format(v any) (string, error) {
+	objectID, ok := v.(primitive.ObjectID)
+	if !ok {
+		return "", fmt.Errorf("_id типа %T вместо ObjectID", v)
+	}
+	return objectID.Hex(), nil
+}
+
+func (ObjectIDCodec) New() any {
+	return primitive.NewObjectID()
+}
+
+var (
+	uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	ulidPattern = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+)
+
+// StringIDCodec хранит id строкой, созданной генератором и проверяемой по шаблону.
+type StringIDCodec struct {
+	gen     IDGenerator
+	pattern *regexp.Regexp
+}
+
+func (c StringIDCodec) Parse(id string) (any, error) {
+	if !c.pattern.MatchString(id) {
+		return nil, ErrInvalidID
+	}
+	return id, nil
+}
+
+func (c StringIDCodec) Format(v any) (string, error) {
+	id, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("_id типа %T вместо строки", v)
+	}
+	return id, nil
+}
+
+func (c StringIDCodec) New() any {
+	return c.gen.NewID()
+}
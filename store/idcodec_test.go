@@ -0,0 +1,37 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestIDCodecs(t *testing.T) {
+	for _, kind := range []string{"objectid", "uuidv7", "ulid"} {
+		codec, err := NewIDCodec(kind)
+		assert.NoError(t, err)
+
+		id, err := codec.Format(codec.New())
+		assert.NoError(t, err, kind)
+
+		key, err := codec.Parse(id)
+		assert.NoError(t, err, kind)
+		formatted, err := codec.Format(key)
+		assert.NoError(t, err, kind)
+		assert.Equal(t, id, formatted, kind)
+
+		_, err = codec.Parse("42")
+		assert.ErrorIs(t, err, ErrInvalidID, kind)
+	}
+
+	_, err := ObjectIDCodec{}.Format("672a1f0c9d1e8a3b4c5d6e7f")
+	assert.Error(t, err)
+
+	key, err := ObjectIDCodec{}.Parse("672a1f0c9d1e8a3b4c5d6e7f")
+	assert.NoError(t, err)
+	assert.IsType(t, primitive.ObjectID{}, key)
+
+	_, err = NewIDCodec("int")
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// IDGenerator выдает строковые id для новых пользователей MemoryStore.
+// Уникальность среди уже сохраненных пользователей проверяет сам MemoryStore.
+type IDGenerator interface {
+	NewID() string
+}
+
+// NewIDGenerator возвращает генератор по имени: counter, uuidv7 или ulid.
+func NewIDGenerator(kind string) (IDGenerator, error) {
+	switch kind {
+	case "", "counter":
+		return &CounterGenerator{}, nil
+	case "uuidv7":
+		return UUIDv7Generator{now: time.Now}, nil
+	case "ulid":
+		return ULIDGenerator{now: time.Now}, nil
+	}
+	return nil, fmt.Errorf("неизвестный генератор id %q", kind)
+}
+
+// CounterGenerator выдает возрастающие числа 1, 2, 3...
+type CounterGenerator struct {
+	last atomic.Uint64
+}
+
+func (g *CounterGenerator) NewID() string {
+	return strconv.FormatUint(g.last.Add(1), 10)
+}
+
+// UUIDv7Generator выдает UUID версии 7 (RFC 9562): время в миллисекундах и случайные биты.
+type UUIDv7Generator struct {
+	now func() time.Time
+}
+
+func (g UUIDv7Generator) NewID() string {
+	var b [16]byte
+	rand.Read(b[6:])
+	ms := uint64(g.now().UnixMilli())
+	binary.BigEndian.PutUint16(b[0:], uint16(ms>>32))
+	binary.BigEndian.PutUint32(b[2:], uint32(ms))
+	b[6] = b[6]&0x0f | 0x70
+	b[8] = b[8]&0x3f | 0x80
+
+	var s [36]byte
+	hex.Encode(s[0:8], b[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], b[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], b[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], b[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:], b[10:])
+	return string(s[:])
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULIDGenerator выдает ULID: 48 бит времени и 80 случайных бит в base32 Крокфорда.
+type ULIDGenerator struct {
+	now func() time.Time
+}
+
+func (g ULIDGenerator) NewID() string {
+	var b [16]byte
+	rand.Read(b[6:])
+	ms := uint64(g.now().UnixMilli())
+	binary.BigEndian.PutUint16(b[0:], uint16(ms>>32))
+	binary.BigEndian.PutUint32(b[2:], uint32(ms))
+
+	// 128 бит кодируются 26 символами по 5 бит, первый символ несет 3 бита.
+	hi := binary.BigEndian.Uint64(b[0:])
+	lo := binary.BigEndian.Uint64(b[8:])
+	var s [26]byte
+	for i := 25; i >= 0; i-- {
+		s[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(s[:])
+}
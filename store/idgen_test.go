@@ -0,0 +1,28 @@
+package store
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIDGenerators(t *testing.T) {
+	now := func() time.Time { return time.UnixMilli(0x0190_1234_5678) }
+
+	counter, err := NewIDGenerator("counter")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", counter.NewID())
+	assert.Equal(t, "2", counter.NewID())
+
+	uuid := UUIDv7Generator{now: now}.NewID()
+	assert.Regexp(t, regexp.MustCompile(`^01901234-5678-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), uuid)
+
+	ulid := ULIDGenerator{now: now}.NewID()
+	assert.Regexp(t, regexp.MustCompile(`^01J0938NKR[0-9A-HJKMNP-TV-Z]{16}$`), ulid)
+	assert.NotEqual(t, ulid, ULIDGenerator{now: now}.NewID())
+
+	_, err = NewIDGenerator("rand")
+	assert.Error(t, err)
+}
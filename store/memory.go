@@ -0,0 +1,266 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"lab8/rsql"
+)
+
+// MemoryStore хранит пользователей в слайсе под мьютексом.
+// Если ctx уже отменен к моменту захвата мьютекса, операция возвращает ctx.Err(),
+// как и MongoStore.
+type MemoryStore struct {
+	mu    sync.Mutex
+	users []User
+	ids   IDGenerator
+}
+
+// NewMemoryStore создает хранилище с начальными пользователями.
+// Если gen равен nil, используется CounterGenerator. Пользователи без версии
+// получают версию 1, как созданные через Create.
+func NewMemoryStore(gen IDGenerator, users ...User) *MemoryStore {
+	if gen == nil {
+		gen = &CounterGenerator{}
+	}
+	users = slices.Clone(users)
+	for i := range users {
+		if users[i].Version == 0 {
+			users[i].Version = 1
+		}
+	}
+	return &MemoryStore{users: users, ids: gen}
+}
+
+// ValidateID принимает любой непустой id: в памяти могут лежать id любых генераторов.
+func (s *MemoryStore) ValidateID(id string) error {
+	if id == "" {
+		return ErrInvalidID
+	}
+	return nil
+}
+
+// matcher возвращает функцию, проверяющую пользователя по условиям фильтра.
+func matcher(f Filter) func(User) bool {
+	prefix := strings.ToLower(f.NamePrefix)
+	contains := strings.ToLower(f.NameContains)
+	include, exclude := textTerms(f.Query)
+
+	return func(user User) bool {
+		name := strings.ToLower(user.Name)
+		if f.Name != "" && !strings.EqualFold(user.Name, f.Name) {
+			return false
+		}
+		if !strings.HasPrefix(name, prefix) || !strings.Contains(name, contains) {
+			return false
+		}
+		if f.Query != "" && !matchText(name, include, exclude) {
+			return false
+		}
+		if f.MinAge != nil && user.Age < *f.MinAge {
+			return false
+		}
+		if f.MaxAge != nil && user.Age > *f.MaxAge {
+			return false
+		}
+		if f.Expr != nil && !rsql.Match(f.Expr, func(field string) any { return fieldValue(user, field) }) {
+			return false
+		}
+		return true
+	}
+}
+
+// textTerms делит поисковую строку на слова для поиска и слова-исключения (с минусом).
+func textTerms(query string) (include, exclude []string) {
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		if t, ok := strings.CutPrefix(term, "-"); ok {
+			if t != "" {
+				exclude = append(exclude, t)
+			}
+			continue
+		}
+		include = append(include, term)
+	}
+	return include, exclude
+}
+
+// matchText повторяет $text в Mongo без стемминга: есть хотя бы одно слово
+// из include и нет ни одного из exclude.
+func matchText(name string, include, exclude []string) bool {
+	words := strings.Fields(name)
+	for _, t := range exclude {
+		if slices.Contains(words, t) {
+			return false
+		}
+	}
+	for _, t := range include {
+		if slices.Contains(words, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryStore) List(ctx context.Context, f Filter) ([]User, error) {
+	match := matcher(f)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var matched []User
+	for _, user := range s.users {
+		if match(user) {
+			matched = append(matched, user)
+		}
+	}
+	slices.SortFunc(matched, func(a, b User) int {
+		return compareToCursor(a, CursorAfter(b, f.Sort), f.Sort)
+	})
+
+	result := []User{}
+	skipped := 0
+	for _, user := range matched {
+		if f.After != nil && compareToCursor(user, *f.After, f.Sort) <= 0 {
+			continue
+		}
+		if skipped < f.Skip {
+			skipped++
+			continue
+		}
+		if f.Limit > 0 && len(result) >= f.Limit {
+			break
+		}
+		result = append(result, user)
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) Count(ctx context.Context, f Filter) (int64, error) {
+	match := matcher(f)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	var n int64
+	for _, user := range s.users {
+		if match(user) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return User{}, err
+	}
+	i := s.indexOf(id)
+	if i < 0 {
+		return User{}, ErrNotFound
+	}
+	return s.users[i], nil
+}
+
+func (s *MemoryStore) Create(ctx context.Context, u User) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return User{}, err
+	}
+	u.ID = s.newID()
+	u.CreatedAt = time.Now().UTC()
+	u.Version = 1
+	s.users = append(s.users, u)
+	return u, nil
+}
+
+// newID берет у генератора первый id, которого еще нет в хранилище.
+// Вызывается под s.mu, поэтому проверка и вставка атомарны.
+func (s *MemoryStore) newID() string {
+	for {
+		id := s.ids.NewID()
+		if s.indexOf(id) < 0 {
+			return id
+		}
+	}
+}
+
+func (s *MemoryStore) indexOf(id string) int {
+	for i, user := range s.users {
+		if user.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *MemoryStore) Update(ctx context.Context, id string, u User, version int64) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return User{}, err
+	}
+	i := s.indexOf(id)
+	if i < 0 {
+		return User{}, ErrNotFound
+	}
+	if version != AnyVersion && s.users[i].Version != version {
+		return User{}, ErrVersionMismatch
+	}
+	s.users[i].Name = u.Name
+	s.users[i].Age = u.Age
+	s.users[i].Email = u.Email
+	s.users[i].Version++
+	return s.users[i], nil
+}
+
+func (s *MemoryStore) Patch(ctx context.Context, id string, fn func(User) (User, error)) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return User{}, err
+	}
+	i := s.indexOf(id)
+	if i < 0 {
+		return User{}, ErrNotFound
+	}
+	u, err := fn(s.users[i])
+	if err != nil {
+		return User{}, err
+	}
+	if u.Name != s.users[i].Name || u.Age != s.users[i].Age || u.Email != s.users[i].Email {
+		s.users[i].Name = u.Name
+		s.users[i].Age = u.Age
+		s.users[i].Email = u.Email
+		s.users[i].Version++
+	}
+	return s.users[i], nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id string, version int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	i := s.indexOf(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	if version != AnyVersion && s.users[i].Version != version {
+		return ErrVersionMismatch
+	}
+	s.users = append(s.users[:i], s.users[i+1:]...)
+	return nil
+}
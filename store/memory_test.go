@@ -0,0 +1,188 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestStore() *MemoryStore {
+	return NewMemoryStore(nil,
+		User{ID: "1", Name: "Виктор", Age: 21},
+		User{ID: "2", Name: "Аркадий", Age: 45},
+		User{ID: "3", Name: "Alice", Age: 25},
+	)
+}
+
+// Тестирование фильтров и пагинации List
+func TestMemoryStoreList(t *testing.T) {
+	s := newTestStore()
+	ctx := context.Background()
+
+	users, err := s.List(ctx, Filter{})
+	assert.NoError(t, err)
+	assert.Len(t, users, 3)
+
+	users, err = s.List(ctx, Filter{NameContains: "аРк"})
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, "Аркадий", users[0].Name)
+
+	users, err = s.List(ctx, Filter{MinAge: ref(22), MaxAge: ref(30)})
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, "Alice", users[0].Name)
+
+	users, err = s.List(ctx, Filter{MaxAge: ref(0)})
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+
+	n, err := s.Count(ctx, Filter{MinAge: ref(22), Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	users, err = s.List(ctx, Filter{Limit: 1, Skip: 1})
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, "2", users[0].ID)
+}
+
+// Тестирование режимов поиска по имени
+func TestMemoryStoreListByName(t *testing.T) {
+	s := NewMemoryStore(nil,
+		User{ID: "1", Name: "Анна Петрова"},
+		User{ID: "2", Name: "Анна"},
+		User{ID: "3", Name: "Иван Петров"},
+		User{ID: "4", Name: "a.*b"},
+	)
+	ctx := context.Background()
+
+	cases := []struct {
+		filter Filter
+		want   []string
+	}{
+		{Filter{Name: "анна"}, []string{"2"}},
+		{Filter{NamePrefix: "АН"}, []string{"1", "2"}},
+		{Filter{NameContains: "петров"}, []string{"1", "3"}},
+		{Filter{NameContains: ".*"}, []string{"4"}},
+		{Filter{Name: "a.*b"}, []string{"4"}},
+		{Filter{Query: "иван анна"}, []string{"1", "2", "3"}},
+		{Filter{Query: "анна -петрова"}, []string{"2"}},
+		{Filter{NamePrefix: "Анна", Query: "петрова"}, []string{"1"}},
+	}
+	for _, c := range cases {
+		users, err := s.List(ctx, c.filter)
+		assert.NoError(t, err)
+		assert.Equal(t, c.want, ids(users), "%+v", c.filter)
+	}
+}
+
+// Тестирование сортировки и выборки после курсора
+func TestMemoryStoreListAfter(t *testing.T) {
+	s := newTestStore()
+	s.users = append(s.users, User{ID: "10", Name: "Bob", Age: 25})
+	ctx := context.Background()
+	sort := []SortField{{Field: "age", Desc: true}}
+
+	users, err := s.List(ctx, Filter{Sort: sort})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2", "3", "10", "1"}, ids(users))
+
+	after := CursorAfter(users[1], sort)
+	users, err = s.List(ctx, Filter{Sort: sort, After: &after})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10", "1"}, ids(users))
+
+	after = Cursor{Values: []any{int64(25)}, ID: "10"}
+	users, err = s.List(ctx, Filter{Sort: sort, After: &after})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1"}, ids(users))
+}
+
+func ids(users []User) []string {
+	var result []string
+	for _, u := range users {
+		result = append(result, u.ID)
+	}
+	return result
+}
+
+// ref возвращает указатель на v для необязательных полей Filter.
+func ref[T any](v T) *T {
+	return &v
+}
+
+// Тестирование создания, обновления и удаления
+func TestMemoryStoreCRUD(t *testing.T) {
+	s := NewMemoryStore(nil)
+	ctx := context.Background()
+
+	created, err := s.Create(ctx, User{Name: "Bob", Age: 30})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, created.ID)
+
+	updated, err := s.Update(ctx, created.ID, User{Name: "Robert", Age: 31}, AnyVersion)
+	assert.NoError(t, err)
+	assert.Equal(t, "Robert", updated.Name)
+	assert.Equal(t, 31, updated.Age)
+	assert.Equal(t, int64(2), updated.Version)
+
+	_, err = s.Update(ctx, created.ID, User{Name: "Bobby"}, 1)
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+	assert.ErrorIs(t, s.Delete(ctx, created.ID, 1), ErrVersionMismatch)
+
+	got, err := s.Get(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, updated, got)
+
+	assert.NoError(t, s.Delete(ctx, created.ID, AnyVersion))
+	_, err = s.Get(ctx, created.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, s.Delete(ctx, created.ID, AnyVersion), ErrNotFound)
+}
+
+// Тестирование версии начальных пользователей: как у созданных, с 1
+func TestMemoryStoreSeedVersion(t *testing.T) {
+	s := NewMemoryStore(nil, User{ID: "1", Name: "Виктор"}, User{ID: "2", Name: "Alice", Version: 5})
+	ctx := context.Background()
+
+	u, err := s.Get(ctx, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), u.Version)
+
+	u, err = s.Get(ctx, "2")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), u.Version)
+}
+
+// Тестирование уникальности id: счетчик пропускает уже занятые значения
+func TestMemoryStoreCreateUniqueIDs(t *testing.T) {
+	s := NewMemoryStore(nil, User{ID: "1", Name: "Виктор"}, User{ID: "3", Name: "Alice"})
+	ctx := context.Background()
+
+	ids := map[string]bool{"1": true, "3": true}
+	for i := 0; i < 100; i++ {
+		u, err := s.Create(ctx, User{Name: "Bob"})
+		assert.NoError(t, err)
+		assert.False(t, ids[u.ID], "повторный id %s", u.ID)
+		ids[u.ID] = true
+	}
+}
+
+// Тестирование отмененного контекста: данные не читаются и не меняются
+func TestMemoryStoreCancelled(t *testing.T) {
+	s := NewMemoryStore(nil, User{ID: "1", Name: "Виктор"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := s.List(ctx, Filter{})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = s.Create(ctx, User{Name: "Bob"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, s.Delete(ctx, "1", AnyVersion), context.Canceled)
+
+	n, err := s.Count(context.Background(), Filter{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// numericAge - строковые age, которые MigrateAges переводит в число.
+const numericAge = `^\s*-?\d+\s*$`
+
+// MigrateAges переводит строковые значения age в целые числа.
+// Строки, которые не являются числом, остаются как есть: их число
+// возвращается в skipped, такие документы нужно исправить вручную.
+// Повторный запуск ничего не меняет.
+func (s *MongoStore) MigrateAges(ctx context.Context) (migrated, skipped int64, err error) {
+	filter := bson.M{"age": bson.M{"$type": "string", "$regex": numericAge}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"age": bson.M{"$convert": bson.M{
+				"input":   bson.M{"$trim": bson.M{"input": "$age"}},
+				"to":      "int",
+				"onError": "$age",
+			}},
+		}}},
+	}
+
+	result, err := s.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, 0, err
+	}
+	skipped, err = s.StringAges(ctx)
+	if err != nil {
+		return result.ModifiedCount, 0, err
+	}
+	return result.ModifiedCount, skipped, nil
+}
+
+// StringAges возвращает число документов, в которых age все еще строка.
+func (s *MongoStore) StringAges(ctx context.Context) (int64, error) {
+	return s.collection.CountDocuments(ctx, bson.M{"age": bson.M{"$type": "string"}})
+}
+
+// mongoAge - age в документе. До миграции age может быть строкой:
+// числовая строка читается как число, остальные - как 0, чтобы один
+// старый документ не ломал чтение всего списка.
+type mongoAge int
+
+func (a *mongoAge) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	v := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bson.TypeInt32, bson.TypeInt64, bson.TypeDouble:
+		*a = mongoAge(v.AsInt64())
+	case bson.TypeString:
+		*a = 0
+		if n, err := strconv.Atoi(strings.TrimSpace(v.StringValue())); err == nil {
+			*a = mongoAge(n)
+		}
+	case bson.TypeNull, bson.TypeUndefined:
+		*a = 0
+	default:
+		return fmt.Errorf("age: неожиданный тип %s", t)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Тестирование чтения age до миграции
+func TestMongoAge(t *testing.T) {
+	tests := []struct {
+		name string
+		age  any
+		want int
+	}{
+		{"int32", int32(25), 25},
+		{"int64", int64(30), 30},
+		{"double", 41.0, 41},
+		{"числовая строка", " 25 ", 25},
+		{"нечисловая строка", "25 лет", 0},
+		{"пустая строка", "", 0},
+		{"null", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := bson.Marshal(bson.M{"name": "Alice", "age": tt.age})
+			require.NoError(t, err)
+			var m mongoUser
+			require.NoError(t, bson.Unmarshal(data, &m))
+			assert.Equal(t, mongoAge(tt.want), m.Age)
+		})
+	}
+
+	data, err := bson.Marshal(bson.M{"age": bson.A{1}})
+	require.NoError(t, err)
+	var m mongoUser
+	assert.Error(t, bson.Unmarshal(data, &m))
+
+	// записывается age всегда числом
+	data, err = bson.Marshal(mongoUser{Age: 25})
+	require.NoError(t, err)
+	age, ok := bson.Raw(data).Lookup("age").AsInt64OK()
+	assert.True(t, ok)
+	assert.Equal(t, int64(25), age)
+}
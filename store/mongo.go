@@ -0,0 +1,556 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+
+	"lab8/breaker"
+	"lab8/requestid"
+	"lab8/rsql"
+)
+
+// mongoUser - представление пользователя в коллекции.
+// Тип _id зависит от IDCodec хранилища.
+type mongoUser struct {
+	ID        any       `bson:"_id,omitempty"`
+	Name      string    `bson:"name"`
+	Age       mongoAge  `bson:"age"`
+	Email     string    `bson:"email,omitempty"`
+	CreatedAt time.Time `bson:"created_at"`
+	Version   int64     `bson:"version"`
+}
+
+// MongoStore хранит пользователей в коллекции MongoDB.
+// Ошибки сети и выбора сервера возвращаются как UnavailableError.
+type MongoStore struct {
+	collection *mongo.Collection
+	ids        IDCodec
+	monitor    *MongoMonitor
+	breaker    *breaker.Breaker
+}
+
+// MongoOptions - необязательные настройки MongoStore.
+type MongoOptions struct {
+	// IDs - тип _id, по умолчанию ObjectIDCodec.
+	IDs IDCodec
+	// Monitor - состояние подключения: пока база недоступна, операции
+	// сразу возвращают UnavailableError.
+	Monitor *MongoMonitor
+	// Breaker - автомат защиты: недоступность базы и таймауты операций
+	// считаются отказами, при разомкнутом автомате операции сразу
+	// возвращают UnavailableError.
+	Breaker *breaker.Breaker
+}
+
+// NewMongoStore создает хранилище поверх коллекции.
+func NewMongoStore(collection *mongo.Collection, opts MongoOptions) *MongoStore {
+	if opts.IDs == nil {
+		opts.IDs = ObjectIDCodec{}
+	}
+	return &MongoStore{collection: collection, ids: opts.IDs, monitor: opts.Monitor, breaker: opts.Breaker}
+}
+
+// begin начинает операцию с базой. Если монитор считает базу недоступной
+// или автомат защиты разомкнут, сразу возвращает UnavailableError. Иначе
+// возвращает finish, который откладывается с адресом ошибки операции: он
+// переводит ошибки недоступности в UnavailableError и сообщает итог автомату.
+func (s *MongoStore) begin(ctx context.Context) (finish func(err *error), err error) {
+	if s.monitor != nil {
+		if err := s.monitor.Err(); err != nil {
+			return nil, err
+		}
+	}
+	done := func(breaker.Outcome) {}
+	if s.breaker != nil {
+		d, err := s.breaker.Allow()
+		var open *breaker.OpenError
+		if errors.As(err, &open) {
+			return nil, &UnavailableError{RetryAfter: open.RetryAfter, Err: err}
+		}
+		done = d
+	}
+	return func(err *error) {
+		*err = s.monitor.unavailable(*err)
+		done(outcome(ctx, *err))
+	}, nil
+}
+
+// comment - комментарий операции для профилировщика MongoDB (system.profile,
+// currentOp): по request_id операцию можно сопоставить с запросом в журнале.
+func comment(ctx context.Context) string {
+	if id := requestid.FromContext(ctx); id != "" {
+		return "usersvc request_id=" + id
+	}
+	return "usersvc"
+}
+
+// outcome - итог операции для автомата защиты. Отказ - база недоступна или
+// не уложилась в срок: истекший таймаут маршрута (контекст операции создает
+// сервер с этим таймаутом) или таймаут драйвера. Отмена запроса клиентом
+// ничего не говорит о базе и не учитывается, иначе отмененный пробный вызов
+// закрыл бы автомат. Ошибки данных - успех: база ответила.
+func outcome(ctx context.Context, err error) breaker.Outcome {
+	switch {
+	case errors.Is(err, ErrUnavailable):
+		return breaker.Failure
+	case errors.Is(ctx.Err(), context.Canceled), errors.Is(err, context.Canceled):
+		return breaker.Ignored
+	case errors.Is(ctx.Err(), context.DeadlineExceeded), mongo.IsTimeout(err):
+		return breaker.Failure
+	}
+	return breaker.Success
+}
+
+// Ping проверяет, что основной сервер MongoDB отвечает. Автомат защиты
+// не учитывает и не ограничивает Ping.
+func (s *MongoStore) Ping(ctx context.Context) error {
+	if s.monitor != nil {
+		if err := s.monitor.Err(); err != nil {
+			return err
+		}
+	}
+	return s.monitor.unavailable(s.collection.Database().Client().Ping(ctx, readpref.Primary()))
+}
+
+func (s *MongoStore) ValidateID(id string) error {
+	_, err := s.ids.Parse(id)
+	return err
+}
+
+func (s *MongoStore) user(m mongoUser) (User, error) {
+	id, err := s.ids.Format(m.ID)
+	if err != nil {
+		return User{}, err
+	}
+	return User{ID: id, Name: m.Name, Age: int(m.Age), Email: m.Email, CreatedAt: m.CreatedAt, Version: m.Version}, nil
+}
+
+// filter строит запрос Mongo по условиям Filter.
+func (s *MongoStore) filter(f Filter) bson.M {
+	filter := bson.M{}
+	var and bson.A
+	if f.Name != "" {
+		and = append(and, bson.M{"name": nameRegex("^" + regexp.QuoteMeta(f.Name) + "$")})
+	}
+	if f.NamePrefix != "" {
+		and = append(and, bson.M{"name": nameRegex("^" + regexp.QuoteMeta(f.NamePrefix))})
+	}
+	if f.NameContains != "" {
+		and = append(and, bson.M{"name": nameRegex(regexp.QuoteMeta(f.NameContains))})
+	}
+	if f.Expr != nil {
+		and = append(and, rsql.ToBSON(f.Expr, func(field string) string { return field }))
+	}
+	if len(and) > 0 {
+		filter["$and"] = and
+	}
+	if f.Query != "" {
+		filter["$text"] = bson.M{"$search": f.Query}
+	}
+
+	if f.MinAge != nil || f.MaxAge != nil {
+		ageFilter := bson.M{}
+		if f.MinAge != nil {
+			ageFilter["$gte"] = *f.MinAge
+		}
+		if f.MaxAge != nil {
+			ageFilter["$lte"] = *f.MaxAge
+		}
+		filter["age"] = ageFilter
+	}
+	return filter
+}
+
+func nameRegex(pattern string) bson.M {
+	return bson.M{"$regex": pattern, "$options": "i"}
+}
+
+// EnsureIndexes создает индексы, нужные для запросов: текстовый индекс по name для Filter.Query.
+// Язык "none" отключает стемминг, чтобы поиск совпадал с MemoryStore.
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: "text"}},
+		Options: options.Index().SetName("name_text").SetDefaultLanguage("none"),
+	})
+	return err
+}
+
+func (s *MongoStore) List(ctx context.Context, f Filter) (_ []User, err error) {
+	finish, err := s.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer finish(&err)
+
+	filter := s.filter(f)
+	if f.After != nil {
+		after, err := s.afterFilter(f.Sort, *f.After)
+		if err != nil {
+			return nil, err
+		}
+		filter = bson.M{"$and": bson.A{filter, after}}
+	}
+
+	sort := bson.D{}
+	for _, sf := range f.Sort {
+		sort = append(sort, bson.E{Key: sf.Field, Value: direction(sf.Desc)})
+	}
+	sort = append(sort, bson.E{Key: "_id", Value: 1})
+
+	findOptions := options.Find().SetComment(comment(ctx))
+	findOptions.SetSort(sort)
+	if len(f.Fields) > 0 {
+		projection := bson.M{}
+		for _, field := range f.Fields {
+			if field != "id" {
+				projection[field] = 1
+			}
+		}
+		for _, sf := range f.Sort {
+			projection[sf.Field] = 1
+		}
+		findOptions.SetProjection(projection)
+	}
+	findOptions.SetSkip(int64(f.Skip))
+	if f.Limit > 0 {
+		findOptions.SetLimit(int64(f.Limit))
+	}
+
+	cur, err := s.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	users := []User{}
+	for cur.Next(ctx) {
+		var m mongoUser
+		if err := cur.Decode(&m); err != nil {
+			return nil, err
+		}
+		user, err := s.user(m)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func direction(desc bool) int {
+	if desc {
+		return -1
+	}
+	return 1
+}
+
+// afterFilter строит условие keyset-пагинации: документы строго после курсора
+// в порядке (sort..., _id). Для полей a, b это
+// {$or: [{a: {$gt: va}}, {a: va, b: {$gt: vb}}, {a: va, b: vb, _id: {$gt: id}}]}.
+// У старых документов нет created_at: MongoDB ставит их как null первыми при
+// возрастании и последними при убывании, а в курсор попадает нулевое время.
+func (s *MongoStore) afterFilter(sort []SortField, c Cursor) (bson.M, error) {
+	key, err := s.ids.Parse(c.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var or bson.A
+	eq := bson.M{}
+	for i, sf := range sort {
+		if cond := afterValue(sf, c.Values[i]); cond != nil {
+			for k, v := range eq {
+				cond[k] = v
+			}
+			or = append(or, cond)
+		}
+		eq[sf.Field] = c.Values[i]
+		if missingValue(c.Values[i]) {
+			eq[sf.Field] = nil
+		}
+	}
+	eq["_id"] = bson.M{"$gt": key}
+	or = append(or, eq)
+	return bson.M{"$or": or}, nil
+}
+
+// afterValue - условие «поле строго после v». Для created_at учитываются
+// документы без поля. Возвращает nil, если после v в этом порядке ничего нет.
+func afterValue(sf SortField, v any) bson.M {
+	op := "$gt"
+	if sf.Desc {
+		op = "$lt"
+	}
+	t, ok := v.(time.Time)
+	switch {
+	case !ok:
+	case t.IsZero() && sf.Desc:
+		return nil
+	case t.IsZero():
+		return bson.M{sf.Field: bson.M{"$ne": nil}}
+	case sf.Desc:
+		// $lt не находит документы без поля, а $not - находит
+		return bson.M{sf.Field: bson.M{"$not": bson.M{"$gte": v}}}
+	}
+	return bson.M{sf.Field: bson.M{op: v}}
+}
+
+// missingValue сообщает, что значение курсора взято из документа без поля.
+func missingValue(v any) bool {
+	t, ok := v.(time.Time)
+	return ok && t.IsZero()
+}
+
+func (s *MongoStore) Count(ctx context.Context, f Filter) (_ int64, err error) {
+	finish, err := s.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer finish(&err)
+
+	return s.collection.CountDocuments(ctx, s.filter(f), options.Count().SetComment(comment(ctx)))
+}
+
+func (s *MongoStore) Get(ctx context.Context, id string) (_ User, err error) {
+	finish, err := s.begin(ctx)
+	if err != nil {
+		return User{}, err
+	}
+	defer finish(&err)
+
+	key, err := s.ids.Parse(id)
+	if err != nil {
+		return User{}, err
+	}
+
+	var m mongoUser
+	err = s.collection.FindOne(ctx, bson.M{"_id": key}, options.FindOne().SetComment(comment(ctx))).Decode(&m)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return User{}, ErrNotFound
+	}
+	if err != nil {
+		return User{}, err
+	}
+	return s.user(m)
+}
+
+func (s *MongoStore) Create(ctx context.Context, u User) (_ User, err error) {
+	finish, err := s.begin(ctx)
+	if err != nil {
+		return User{}, err
+	}
+	defer finish(&err)
+
+	m := mongoUser{
+		ID:    s.ids.New(),
+		Name:  u.Name,
+		Age:   mongoAge(u.Age),
+		Email: u.Email,
+		// Mongo хранит время с точностью до миллисекунд
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+		Version:   1,
+	}
+	if _, err := s.collection.InsertOne(ctx, m, options.InsertOne().SetComment(comment(ctx))); err != nil {
+		return User{}, err
+	}
+	return s.user(m)
+}
+
+// versionFilter возвращает условие на _id и, если version не AnyVersion, на версию.
+// У документов, созданных до появления версий, поля version нет - это версия 0.
+func versionFilter(key any, version int64) bson.M {
+	filter := bson.M{"_id": key}
+	switch version {
+	case AnyVersion:
+	case 0:
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	default:
+		filter["version"] = version
+	}
+	return filter
+}
+
+// missing объясняет, почему условие versionFilter ничего не нашло:
+// документа нет (ErrNotFound) или у него другая версия (ErrVersionMismatch).
+func (s *MongoStore) missing(ctx context.Context, key any, version int64) error {
+	if version == AnyVersion {
+		return ErrNotFound
+	}
+	n, err := s.collection.CountDocuments(ctx, bson.M{"_id": key}, options.Count().SetComment(comment(ctx)))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return ErrVersionMismatch
+}
+
+func (s *MongoStore) Update(ctx context.Context, id string, u User, version int64) (_ User, err error) {
+	finish, err := s.begin(ctx)
+	if err != nil {
+		return User{}, err
+	}
+	defer finish(&err)
+
+	key, err := s.ids.Parse(id)
+	if err != nil {
+		return User{}, err
+	}
+
+	update := replaceUpdate(u)
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetComment(comment(ctx))
+	var m mongoUser
+	err = s.collection.FindOneAndUpdate(ctx, versionFilter(key, version), update, opts).Decode(&m)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return User{}, s.missing(ctx, key, version)
+	}
+	if err != nil {
+		return User{}, err
+	}
+	return s.user(m)
+}
+
+// maxPatchAttempts - сколько раз Patch перечитывает пользователя,
+// если его изменили между чтением и записью.
+const maxPatchAttempts = 5
+
+// Patch читает пользователя, применяет fn и записывает только изменившиеся
+// поля через $set и $unset (пустое необязательное поле удаляется). Запись
+// выполняется, только если версия не изменилась с момента чтения, иначе
+// попытка повторяется.
+func (s *MongoStore) Patch(ctx context.Context, id string, fn func(User) (User, error)) (_ User, err error) {
+	finish, err := s.begin(ctx)
+	if err != nil {
+		return User{}, err
+	}
+	defer finish(&err)
+
+	key, err := s.ids.Parse(id)
+	if err != nil {
+		return User{}, err
+	}
+
+	for attempt := 0; attempt < maxPatchAttempts; attempt++ {
+		var m mongoUser
+		err := s.collection.FindOne(ctx, bson.M{"_id": key}, options.FindOne().SetComment(comment(ctx))).Decode(&m)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return User{}, ErrNotFound
+		}
+		if err != nil {
+			return User{}, err
+		}
+		current, err := s.user(m)
+		if err != nil {
+			return User{}, err
+		}
+
+		next, err := fn(current)
+		if err != nil {
+			return User{}, err
+		}
+
+		update := patchUpdate(current, next)
+		if update == nil {
+			return current, nil
+		}
+		result, err := s.collection.UpdateOne(ctx, versionFilter(key, current.Version), update,
+			options.Update().SetComment(comment(ctx)))
+		if err != nil {
+			return User{}, err
+		}
+		if result.MatchedCount == 1 {
+			next.ID, next.CreatedAt = current.ID, current.CreatedAt
+			next.Version = current.Version + 1
+			return next, nil
+		}
+	}
+	return User{}, ErrConflict
+}
+
+// optionalFields - поля mongoUser с omitempty: пустое значение в Patch
+// удаляет поле из документа, а не записывает "".
+var optionalFields = []string{"email"}
+
+// replaceUpdate строит обновление Update: все изменяемые поля - в $set,
+// пустые необязательные - в $unset, как в Patch, чтобы одно и то же
+// состояние хранилось документом одной формы.
+func replaceUpdate(u User) bson.M {
+	set, unset := bson.M{}, bson.M{}
+	for _, field := range mutableFields {
+		value := fieldValue(u, field)
+		if slices.Contains(optionalFields, field) && reflect.ValueOf(value).IsZero() {
+			unset[field] = ""
+			continue
+		}
+		set[field] = value
+	}
+
+	update := bson.M{"$set": set, "$inc": bson.M{"version": 1}}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return update
+}
+
+// patchUpdate строит обновление Patch: изменившиеся поля - в $set, очищенные
+// необязательные - в $unset. Возвращает nil, если ничего не изменилось.
+func patchUpdate(current, next User) bson.M {
+	set, unset := bson.M{}, bson.M{}
+	for _, field := range mutableFields {
+		old, value := fieldValue(current, field), fieldValue(next, field)
+		switch {
+		case old == value:
+		case slices.Contains(optionalFields, field) && reflect.ValueOf(value).IsZero():
+			unset[field] = ""
+		default:
+			set[field] = value
+		}
+	}
+	if len(set) == 0 && len(unset) == 0 {
+		return nil
+	}
+
+	update := bson.M{"$inc": bson.M{"version": 1}}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return update
+}
+
+func (s *MongoStore) Delete(ctx context.Context, id string, version int64) (err error) {
+	finish, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer finish(&err)
+
+	key, err := s.ids.Parse(id)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.collection.DeleteOne(ctx, versionFilter(key, version), options.Delete().SetComment(comment(ctx)))
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return s.missing(ctx, key, version)
+	}
+	return nil
+}
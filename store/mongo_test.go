@@ -0,0 +1,144 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"lab8/requestid"
+	"lab8/rsql"
+)
+
+// Тестирование комментария операций для профилировщика MongoDB
+func TestComment(t *testing.T) {
+	assert.Equal(t, "usersvc", comment(context.Background()))
+	ctx := requestid.NewContext(context.Background(), "req-1")
+	assert.Equal(t, "usersvc request_id=req-1", comment(ctx))
+}
+
+// Тестирование документа обновления Patch
+func TestPatchUpdate(t *testing.T) {
+	current := User{ID: "1", Name: "Alice", Age: 25, Email: "alice@example.com", Version: 3}
+
+	tests := []struct {
+		name string
+		next func(u User) User
+		want bson.M
+	}{
+		{"без изменений", func(u User) User { return u }, nil},
+		{"имя", func(u User) User { u.Name = "Bob"; return u }, bson.M{
+			"$set": bson.M{"name": "Bob"},
+			"$inc": bson.M{"version": 1},
+		}},
+		// age 0 допустим и должен храниться, иначе пропадет из фильтров по возрасту
+		{"age 0", func(u User) User { u.Age = 0; return u }, bson.M{
+			"$set": bson.M{"age": 0},
+			"$inc": bson.M{"version": 1},
+		}},
+		{"очистка email", func(u User) User { u.Email = ""; return u }, bson.M{
+			"$unset": bson.M{"email": ""},
+			"$inc":   bson.M{"version": 1},
+		}},
+		{"несколько полей", func(u User) User { u.Age, u.Email = 0, ""; return u }, bson.M{
+			"$set":   bson.M{"age": 0},
+			"$unset": bson.M{"email": ""},
+			"$inc":   bson.M{"version": 1},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, patchUpdate(current, tt.next(current)))
+		})
+	}
+}
+
+// Тестирование документа обновления Update: пустой email удаляется, как в Patch
+func TestReplaceUpdate(t *testing.T) {
+	assert.Equal(t, bson.M{
+		"$set": bson.M{"name": "Alice", "age": 0, "email": "alice@example.com"},
+		"$inc": bson.M{"version": 1},
+	}, replaceUpdate(User{Name: "Alice", Email: "alice@example.com"}))
+
+	assert.Equal(t, bson.M{
+		"$set":   bson.M{"name": "Alice", "age": 25},
+		"$unset": bson.M{"email": ""},
+		"$inc":   bson.M{"version": 1},
+	}, replaceUpdate(User{Name: "Alice", Age: 25}))
+}
+
+// Тестирование запроса Mongo по Filter
+func TestMongoFilter(t *testing.T) {
+	s := NewMongoStore(nil, MongoOptions{})
+
+	expr, err := rsql.Parse("age<18")
+	require.NoError(t, err)
+	require.NoError(t, rsql.Bind(expr, FilterSchema))
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bson.M
+	}{
+		{"пустой", Filter{}, bson.M{}},
+		{"возраст", Filter{MinAge: ref(18), MaxAge: ref(30)}, bson.M{"age": bson.M{"$gte": 18, "$lte": 30}}},
+		// 0 - настоящая граница, а не ее отсутствие
+		{"max_age 0", Filter{MaxAge: ref(0)}, bson.M{"age": bson.M{"$lte": 0}}},
+		{"имя", Filter{NamePrefix: "a.b"}, bson.M{"$and": bson.A{
+			bson.M{"name": bson.M{"$regex": `^a\.b`, "$options": "i"}},
+		}}},
+		{"выражение и поиск", Filter{Expr: expr, Query: "alice"}, bson.M{
+			"$and":  bson.A{bson.M{"age": bson.M{"$lt": int64(18)}}},
+			"$text": bson.M{"$search": "alice"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, s.filter(tt.filter))
+		})
+	}
+}
+
+// Тестирование условия «после курсора» для keyset-пагинации
+func TestAfterFilter(t *testing.T) {
+	s := NewMongoStore(nil, MongoOptions{})
+	id := primitive.NewObjectID()
+
+	got, err := s.afterFilter([]SortField{{Field: "name"}, {Field: "age", Desc: true}},
+		Cursor{Values: []any{"Bob", 30}, ID: id.Hex()})
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"name": bson.M{"$gt": "Bob"}},
+		bson.M{"name": "Bob", "age": bson.M{"$lt": 30}},
+		bson.M{"name": "Bob", "age": 30, "_id": bson.M{"$gt": id}},
+	}}, got)
+
+	// документы без created_at: в курсоре нулевое время, в базе - null
+	got, err = s.afterFilter([]SortField{{Field: "created_at"}}, Cursor{Values: []any{time.Time{}}, ID: id.Hex()})
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"created_at": bson.M{"$ne": nil}},
+		bson.M{"created_at": nil, "_id": bson.M{"$gt": id}},
+	}}, got)
+
+	got, err = s.afterFilter([]SortField{{Field: "created_at", Desc: true}}, Cursor{Values: []any{time.Time{}}, ID: id.Hex()})
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"created_at": nil, "_id": bson.M{"$gt": id}},
+	}}, got)
+
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	got, err = s.afterFilter([]SortField{{Field: "created_at", Desc: true}}, Cursor{Values: []any{created}, ID: id.Hex()})
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"created_at": bson.M{"$not": bson.M{"$gte": created}}},
+		bson.M{"created_at": created, "_id": bson.M{"$gt": id}},
+	}}, got)
+
+	_, err = s.afterFilter(nil, Cursor{ID: "not-an-id"})
+	assert.ErrorIs(t, err, ErrInvalidID)
+}
@@ -0,0 +1,127 @@
+package store
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/description"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+// UnavailableError - база недоступна; RetryAfter подсказывает, когда ее
+// состояние будет проверено снова. errors.Is(err, ErrUnavailable) == true.
+type UnavailableError struct {
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *UnavailableError) Error() string {
+	if e.Err != nil {
+		return ErrUnavailable.Error() + ": " + e.Err.Error()
+	}
+	return ErrUnavailable.Error()
+}
+
+func (e *UnavailableError) Is(target error) bool { return target == ErrUnavailable }
+
+func (e *UnavailableError) Unwrap() error { return e.Err }
+
+// MongoMonitor отслеживает доступность MongoDB по событиям мониторинга
+// серверов драйвера: база доступна, пока в топологии есть сервер,
+// принимающий запись. Драйвер сам переподключается, монитор только
+// фиксирует состояние, чтобы запросы не ждали таймаута выбора сервера.
+type MongoMonitor struct {
+	heartbeat time.Duration
+	onChange  func(available bool, err error)
+
+	mu        sync.Mutex
+	available bool
+	since     time.Time
+	lastErr   error
+}
+
+// NewMongoMonitor создает монитор. heartbeat - интервал проверки серверов
+// драйвером, он же RetryAfter в UnavailableError. onChange вызывается при
+// смене состояния и может быть nil. До первого события база считается недоступной.
+func NewMongoMonitor(heartbeat time.Duration, onChange func(available bool, err error)) *MongoMonitor {
+	return &MongoMonitor{heartbeat: heartbeat, onChange: onChange, since: time.Now()}
+}
+
+// ServerMonitor возвращает обработчики событий для options.Client().SetServerMonitor.
+func (m *MongoMonitor) ServerMonitor() *event.ServerMonitor {
+	return &event.ServerMonitor{
+		TopologyDescriptionChanged: func(e *event.TopologyDescriptionChangedEvent) {
+			m.update(e.NewDescription)
+		},
+	}
+}
+
+func (m *MongoMonitor) update(t description.Topology) {
+	available, lastErr := false, error(nil)
+	for _, s := range t.Servers {
+		switch s.Kind {
+		case description.Standalone, description.RSPrimary, description.Mongos, description.LoadBalancer:
+			available = true
+		}
+		if s.LastError != nil {
+			lastErr = s.LastError
+		}
+	}
+	if available {
+		lastErr = nil
+	} else if lastErr == nil {
+		lastErr = errors.New("нет доступного сервера")
+	}
+	m.set(available, lastErr)
+}
+
+func (m *MongoMonitor) set(available bool, err error) {
+	m.mu.Lock()
+	changed := m.available != available
+	m.available, m.lastErr = available, err
+	if changed {
+		m.since = time.Now()
+	}
+	m.mu.Unlock()
+
+	// вызывается без мьютекса: обработчик может читать состояние
+	if changed && m.onChange != nil {
+		m.onChange(available, err)
+	}
+}
+
+// Available сообщает, доступна ли база, и с какого момента в этом состоянии.
+func (m *MongoMonitor) Available() (bool, time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.available, m.since
+}
+
+// Err возвращает UnavailableError, если база недоступна, иначе nil.
+func (m *MongoMonitor) Err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.available {
+		return nil
+	}
+	return &UnavailableError{RetryAfter: m.heartbeat, Err: m.lastErr}
+}
+
+// unavailable переводит ошибки сети и выбора сервера в UnavailableError,
+// остальные ошибки возвращает как есть. m может быть nil.
+func (m *MongoMonitor) unavailable(err error) error {
+	if err == nil || errors.Is(err, ErrUnavailable) {
+		return err
+	}
+	if mongo.IsNetworkError(err) || errors.As(err, &topology.ServerSelectionError{}) {
+		var retryAfter time.Duration
+		if m != nil {
+			retryAfter = m.heartbeat
+		}
+		return &UnavailableError{RetryAfter: retryAfter, Err: err}
+	}
+	return err
+}
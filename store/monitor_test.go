@@ -0,0 +1,128 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo/description"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+
+	"lab8/breaker"
+)
+
+// Тестирование состояния базы по описанию топологии
+func TestMongoMonitor(t *testing.T) {
+	var changes []bool
+	m := NewMongoMonitor(10*time.Second, func(available bool, err error) {
+		changes = append(changes, available)
+	})
+
+	// до первого события база недоступна
+	err := m.Err()
+	assert.ErrorIs(t, err, ErrUnavailable)
+
+	m.update(description.Topology{Servers: []description.Server{{Kind: description.Standalone}}})
+	available, _ := m.Available()
+	assert.True(t, available)
+	assert.NoError(t, m.Err())
+
+	refused := errors.New("connection refused")
+	m.update(description.Topology{Servers: []description.Server{{Kind: description.Unknown, LastError: refused}}})
+	err = m.Err()
+	var unavailable *UnavailableError
+	assert.ErrorAs(t, err, &unavailable)
+	assert.Equal(t, 10*time.Second, unavailable.RetryAfter)
+	assert.ErrorIs(t, err, refused)
+
+	// в наборе реплик без primary запись невозможна
+	m.update(description.Topology{Servers: []description.Server{{Kind: description.RSSecondary}}})
+	assert.ErrorIs(t, m.Err(), ErrUnavailable)
+
+	m.update(description.Topology{Servers: []description.Server{
+		{Kind: description.RSSecondary},
+		{Kind: description.RSPrimary},
+	}})
+	assert.NoError(t, m.Err())
+
+	assert.Equal(t, []bool{true, false, true}, changes)
+}
+
+// Тестирование перевода ошибок драйвера в ErrUnavailable
+func TestUnavailable(t *testing.T) {
+	m := NewMongoMonitor(time.Second, nil)
+
+	selection := fmt.Errorf("find: %w", topology.ServerSelectionError{Wrapped: errors.New("no reachable servers")})
+	assert.ErrorIs(t, m.unavailable(selection), ErrUnavailable)
+
+	var nilMonitor *MongoMonitor
+	assert.ErrorIs(t, nilMonitor.unavailable(selection), ErrUnavailable)
+
+	assert.Equal(t, ErrNotFound, m.unavailable(ErrNotFound))
+	assert.NoError(t, m.unavailable(nil))
+}
+
+// Тестирование автомата защиты вокруг операций MongoStore
+func TestMongoStoreBreaker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cb := breaker.New(breaker.Settings{
+		FailureThreshold: 2,
+		OpenTimeout:      30 * time.Second,
+		Now:              func() time.Time { return now },
+	})
+	s := NewMongoStore(nil, MongoOptions{Breaker: cb})
+
+	var runCtx func(ctx context.Context, opErr error) error
+	run := func(opErr error) error {
+		return runCtx(context.Background(), opErr)
+	}
+	runCtx = func(ctx context.Context, opErr error) error {
+		finish, err := s.begin(ctx)
+		if err != nil {
+			return err
+		}
+		finish(&opErr)
+		return opErr
+	}
+
+	// ошибки данных и отмена клиентом не размыкают автомат
+	assert.Equal(t, ErrNotFound, run(ErrNotFound))
+	assert.ErrorIs(t, run(context.Canceled), context.Canceled)
+	assert.ErrorIs(t, run(context.Canceled), context.Canceled)
+	gone, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, runCtx(gone, timeoutErr{}), timeoutErr{})
+	assert.Equal(t, breaker.Closed, cb.Snapshot().State)
+
+	// медленная база: истек таймаут маршрута или сработал таймаут драйвера
+	expired, cancelExpired := context.WithDeadline(context.Background(), now)
+	defer cancelExpired()
+	assert.ErrorIs(t, runCtx(expired, context.DeadlineExceeded), context.DeadlineExceeded)
+	assert.ErrorIs(t, run(timeoutErr{}), timeoutErr{})
+	assert.Equal(t, breaker.Open, cb.Snapshot().State)
+
+	now = now.Add(10 * time.Second)
+	err := run(nil)
+	var unavailable *UnavailableError
+	require.ErrorAs(t, err, &unavailable)
+	assert.Equal(t, 20*time.Second, unavailable.RetryAfter)
+	assert.ErrorIs(t, err, breaker.ErrOpen)
+
+	// клиент ушел во время пробного вызова: база не ответила, автомат не закрыт
+	now = now.Add(20 * time.Second)
+	assert.ErrorIs(t, run(context.Canceled), context.Canceled)
+	assert.Equal(t, breaker.HalfOpen, cb.Snapshot().State)
+	assert.NoError(t, run(nil))
+	assert.Equal(t, breaker.Closed, cb.Snapshot().State)
+}
+
+// timeoutErr - сетевой таймаут, как его видит драйвер.
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string   { return "i/o timeout" }
+func (timeoutErr) Timeout() bool   { return true }
+func (timeoutErr) Temporary() bool { return true }
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// SortField - поле сортировки. Последним ключом сортировки всегда идет id.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Cursor - позиция для постраничной выборки по ключу (keyset):
+// значения полей сортировки и id последнего полученного пользователя.
+// List с Filter.After возвращает пользователей строго после этой позиции.
+type Cursor struct {
+	Values []any
+	ID     string
+}
+
+// CursorAfter возвращает курсор, указывающий на пользователя u при сортировке sort.
+func CursorAfter(u User, sort []SortField) Cursor {
+	c := Cursor{ID: u.ID}
+	for _, sf := range sort {
+		c.Values = append(c.Values, fieldValue(u, sf.Field))
+	}
+	return c
+}
+
+// CursorValue приводит значение поля из разобранного курсора (JSON) к типу,
+// который возвращает CursorAfter: числа к int64, created_at к time.Time.
+func CursorValue(field string, raw any) (any, error) {
+	switch field {
+	case "name":
+		if s, ok := raw.(string); ok {
+			return s, nil
+		}
+	case "age":
+		switch v := raw.(type) {
+		case float64:
+			return int64(v), nil
+		case interface{ Int64() (int64, error) }:
+			return v.Int64()
+		}
+	case "created_at":
+		if s, ok := raw.(string); ok {
+			return time.Parse(time.RFC3339Nano, s)
+		}
+	}
+	return nil, fmt.Errorf("значение %v не подходит для поля %q", raw, field)
+}
+
+// fieldValue возвращает значение поля пользователя для сортировки, курсоров и фильтров.
+func fieldValue(u User, field string) any {
+	switch field {
+	case "name":
+		return u.Name
+	case "age":
+		return u.Age
+	case "email":
+		return u.Email
+	case "created_at":
+		return u.CreatedAt
+	}
+	panic(fmt.Sprintf("store: неизвестное поле %q", field))
+}
+
+// compareValues сравнивает значения одного поля. Числа приводятся к int64,
+// потому что после разбора курсора int может прийти как int64.
+func compareValues(a, b any) int {
+	switch a := a.(type) {
+	case string:
+		return cmp.Compare(a, b.(string))
+	case int:
+		return cmp.Compare(int64(a), toInt64(b))
+	case int64:
+		return cmp.Compare(a, toInt64(b))
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	}
+	panic(fmt.Sprintf("store: сравнение значений типа %T", a))
+}
+
+func toInt64(v any) int64 {
+	switch v := v.(type) {
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	}
+	panic(fmt.Sprintf("store: %T вместо целого числа", v))
+}
+
+// compareIDs сравнивает id так, чтобы числовые id счетчика шли по возрастанию:
+// "9" < "10". Остальные id (uuidv7, ulid, ObjectID) сравниваются как строки.
+func compareIDs(a, b string) int {
+	x, errA := strconv.ParseUint(a, 10, 64)
+	y, errB := strconv.ParseUint(b, 10, 64)
+	if errA == nil && errB == nil {
+		return cmp.Compare(x, y)
+	}
+	return cmp.Compare(a, b)
+}
+
+// compareToCursor сравнивает пользователя с позицией курсора в порядке sort.
+func compareToCursor(u User, c Cursor, sort []SortField) int {
+	for i, sf := range sort {
+		if d := compareValues(fieldValue(u, sf.Field), c.Values[i]); d != 0 {
+			if sf.Desc {
+				return -d
+			}
+			return d
+		}
+	}
+	return compareIDs(u.ID, c.ID)
+}
@@ -0,0 +1,93 @@
+// Package store описывает хранилище пользователей и его реализации:
+// в памяти (MemoryStore) и в MongoDB (MongoStore).
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"lab8/rsql"
+)
+
+// User - пользователь. Теги validate описывают правила пакета validate:
+// id, created_at и version назначает хранилище, при создании их передавать нельзя.
+type User struct {
+	ID        string    `json:"id,omitempty" validate:"create:empty"`
+	Name      string    `json:"name" validate:"required,maxlen=100,personname"`
+	Age       int       `json:"age" validate:"min=0,max=150"`
+	Email     string    `json:"email" validate:"maxlen=254,email"`
+	CreatedAt time.Time `json:"created_at" validate:"create:empty"`
+	// Version увеличивается при каждом изменении пользователя, начиная с 1.
+	Version int64 `json:"version" validate:"create:empty"`
+}
+
+// AnyVersion - не проверять версию в Update и Delete.
+const AnyVersion int64 = -1
+
+var (
+	// Fields - поля пользователя, которые можно запросить в Filter.Fields.
+	Fields = []string{"id", "name", "age", "email", "created_at"}
+	// SortableFields - поля, допустимые в Filter.Sort.
+	SortableFields = []string{"name", "age", "created_at"}
+	// mutableFields - поля, которые меняют Update и Patch.
+	mutableFields = []string{"name", "age", "email"}
+	// FilterSchema - поля и типы, допустимые в Filter.Expr.
+	FilterSchema = rsql.Schema{"name": rsql.String, "age": rsql.Int, "email": rsql.String, "created_at": rsql.Time}
+)
+
+// Filter задает условия выборки для List.
+// Выдача упорядочена по Sort, а затем по id.
+type Filter struct {
+	// Name, NamePrefix и NameContains сравнивают имя без учета регистра:
+	// целиком, по началу и по вхождению. Значения - обычный текст, не регулярные выражения.
+	Name         string
+	NamePrefix   string
+	NameContains string
+	// Query - полнотекстовый поиск по имени: подходят пользователи с любым из слов,
+	// слово с минусом исключает пользователей, у которых оно есть.
+	Query string
+	// MinAge и MaxAge - границы возраста включительно, nil - без границы.
+	MinAge *int
+	MaxAge *int
+	// Expr - дополнительное условие на языке RSQL, прошедшее rsql.Bind с FilterSchema.
+	Expr rsql.Node
+	Sort []SortField
+	// Fields - если не пуст, хранилище может заполнить только эти поля
+	// (id и поля сортировки заполняются всегда).
+	Fields []string
+	Limit  int
+	Skip   int
+	// After - вернуть только пользователей после курсора; Values должны соответствовать Sort.
+	After *Cursor
+}
+
+var (
+	ErrNotFound  = errors.New("пользователь не найден")
+	ErrInvalidID = errors.New("неправильный id пользователя")
+	// ErrConflict - пользователь менялся параллельно, и изменение не удалось применить.
+	ErrConflict = errors.New("пользователь изменен другим запросом")
+	// ErrVersionMismatch - версия пользователя не совпала с ожидаемой.
+	ErrVersionMismatch = errors.New("версия пользователя не совпадает")
+	// ErrUnavailable - хранилище временно недоступно, запрос можно повторить.
+	ErrUnavailable = errors.New("хранилище недоступно")
+)
+
+// UserStore - общий интерфейс хранилища, через который работают обработчики.
+// Get, Update, Patch и Delete возвращают ErrNotFound, если пользователя нет,
+// и ErrInvalidID, если id не подходит для хранилища. Update и Delete с версией,
+// отличной от AnyVersion, возвращают ErrVersionMismatch, если текущая версия другая.
+type UserStore interface {
+	// ValidateID проверяет формат id без обращения к данным.
+	ValidateID(id string) error
+	List(ctx context.Context, f Filter) ([]User, error)
+	// Count возвращает число пользователей под фильтром без учета Limit и Skip.
+	Count(ctx context.Context, f Filter) (int64, error)
+	Get(ctx context.Context, id string) (User, error)
+	Create(ctx context.Context, u User) (User, error)
+	Update(ctx context.Context, id string, u User, version int64) (User, error)
+	// Patch атомарно применяет fn к текущему пользователю и сохраняет изменившиеся
+	// поля. Если fn вернула ошибку, пользователь не меняется, а ошибка возвращается как есть.
+	Patch(ctx context.Context, id string, fn func(User) (User, error)) (User, error)
+	Delete(ctx context.Context, id string, version int64) error
+}
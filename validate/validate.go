@@ -0,0 +1,233 @@
+// Package validate проверяет структуры по правилам из тега validate
+// и возвращает сразу все нарушения с путями полей.
+//
+// Правила перечисляются через запятую: `validate:"required,maxlen=100"`.
+// Правило с префиксом сценария применяется только в нем: `validate:"create:empty"`.
+// Кроме required и empty, правила не применяются к пустым строкам.
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Scenario - операция, для которой проверяется значение.
+type Scenario string
+
+const (
+	Create Scenario = "create"
+	Update Scenario = "update"
+)
+
+// Violation - нарушение правила в поле.
+type Violation struct {
+	// Field - путь поля по именам из тега json: name, address.city.
+	Field string
+	// Rule - имя нарушенного правила, Param - его параметр (100 для maxlen=100).
+	Rule  string
+	Param string
+	// Message - готовый текст; если пуст, текст строится по Rule.
+	Message string
+}
+
+// Func проверяет значение поля; param - текст после "=" в правиле.
+type Func func(v reflect.Value, param string) bool
+
+// Validator хранит правила. Нулевое значение не готово к работе, используйте New.
+type Validator struct {
+	mu    sync.RWMutex
+	rules map[string]Func
+	// fields - разобранные теги по типам структур
+	fields sync.Map
+}
+
+// New возвращает Validator со встроенными правилами:
+// required, empty, min, max, minlen, maxlen, email и personname.
+func New() *Validator {
+	return &Validator{rules: map[string]Func{
+		"required": required,
+		"empty":    func(v reflect.Value, _ string) bool { return v.IsZero() },
+		"min":      func(v reflect.Value, p string) bool { return number(v) >= parseParam(p) },
+		"max":      func(v reflect.Value, p string) bool { return number(v) <= parseParam(p) },
+		"minlen": func(v reflect.Value, p string) bool {
+			return float64(utf8.RuneCountInString(v.String())) >= parseParam(p)
+		},
+		"maxlen": func(v reflect.Value, p string) bool {
+			return float64(utf8.RuneCountInString(v.String())) <= parseParam(p)
+		},
+		"email":      func(v reflect.Value, _ string) bool { return Email(v.String()) },
+		"personname": func(v reflect.Value, _ string) bool { return PersonName(v.String()) },
+	}}
+}
+
+// Register добавляет правило или заменяет правило с тем же именем.
+func (val *Validator) Register(name string, fn Func) {
+	val.mu.Lock()
+	defer val.mu.Unlock()
+	val.rules[name] = fn
+}
+
+// Struct проверяет структуру s (или указатель на нее) в сценарии sc.
+// Для каждого поля сообщается только первое нарушенное правило.
+// Неизвестное правило в теге - ошибка программы, Struct паникует.
+func (val *Validator) Struct(s any, sc Scenario) []Violation {
+	v := reflect.Indirect(reflect.ValueOf(s))
+	return val.walk(v, "", sc, nil)
+}
+
+type rule struct {
+	name     string
+	param    string
+	scenario Scenario
+}
+
+type field struct {
+	index  int
+	path   string
+	rules  []rule
+	nested bool
+}
+
+func (val *Validator) walk(v reflect.Value, prefix string, sc Scenario, out []Violation) []Violation {
+	for _, f := range val.fieldsOf(v.Type()) {
+		fv := v.Field(f.index)
+		path := prefix + f.path
+		if violation, ok := val.check(fv, f.rules, sc); !ok {
+			violation.Field = path
+			out = append(out, violation)
+			continue
+		}
+		if f.nested {
+			out = val.walk(fv, path+".", sc, out)
+		}
+	}
+	return out
+}
+
+func (val *Validator) check(v reflect.Value, rules []rule, sc Scenario) (Violation, bool) {
+	val.mu.RLock()
+	defer val.mu.RUnlock()
+	for _, r := range rules {
+		if r.scenario != "" && r.scenario != sc {
+			continue
+		}
+		if v.Kind() == reflect.String && v.Len() == 0 && r.name != "required" && r.name != "empty" {
+			continue
+		}
+		fn, ok := val.rules[r.name]
+		if !ok {
+			panic(fmt.Sprintf("validate: неизвестное правило %q", r.name))
+		}
+		if !fn(v, r.param) {
+			return Violation{Rule: r.name, Param: r.param}, false
+		}
+	}
+	return Violation{}, true
+}
+
+// fieldsOf разбирает теги структуры один раз на тип.
+func (val *Validator) fieldsOf(t reflect.Type) []field {
+	if cached, ok := val.fields.Load(t); ok {
+		return cached.([]field)
+	}
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		f := field{
+			index:  i,
+			path:   name,
+			rules:  parseTag(sf.Tag.Get("validate")),
+			nested: sf.Type.Kind() == reflect.Struct && sf.Type != reflect.TypeOf(time.Time{}),
+		}
+		if len(f.rules) > 0 || f.nested {
+			fields = append(fields, f)
+		}
+	}
+	val.fields.Store(t, fields)
+	return fields
+}
+
+func parseTag(tag string) []rule {
+	var rules []rule
+	for _, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		var r rule
+		if sc, rest, ok := strings.Cut(part, ":"); ok && !strings.Contains(sc, "=") {
+			r.scenario, part = Scenario(sc), rest
+		}
+		r.name, r.param, _ = strings.Cut(part, "=")
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+func required(v reflect.Value, _ string) bool {
+	if v.Kind() == reflect.String {
+		return strings.TrimSpace(v.String()) != ""
+	}
+	return !v.IsZero()
+}
+
+func number(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	}
+	panic(fmt.Sprintf("validate: правило для чисел на поле типа %s", v.Type()))
+}
+
+func parseParam(p string) float64 {
+	f, err := strconv.ParseFloat(p, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validate: неверный параметр правила %q", p))
+	}
+	return f
+}
+
+// Email проверяет, что s - адрес вида user@example.com без имени и угловых скобок.
+func Email(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return false
+	}
+	_, domain, _ := strings.Cut(s, "@")
+	return strings.Contains(domain, ".") && !strings.HasSuffix(domain, ".")
+}
+
+// PersonName проверяет, что имя начинается с буквы и состоит из букв,
+// пробелов, дефисов, апострофов и точек.
+func PersonName(s string) bool {
+	for i, r := range s {
+		switch {
+		case unicode.IsLetter(r), i > 0 && unicode.Is(unicode.Mn, r):
+		case i > 0 && strings.ContainsRune(" -'.", r):
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,86 @@
+package validate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type person struct {
+	ID      string  `json:"id" validate:"create:empty"`
+	Name    string  `json:"name" validate:"required,minlen=2,maxlen=5,personname"`
+	Age     int     `json:"age" validate:"min=0,max=150"`
+	Email   string  `json:"email" validate:"email"`
+	Address address `json:"address"`
+	Nick    string  `validate:"even"`
+	skipped string
+}
+
+// Тестирование сбора всех нарушений с путями полей
+func TestStruct(t *testing.T) {
+	v := New()
+	v.Register("even", func(v reflect.Value, _ string) bool { return v.Len()%2 == 0 })
+
+	p := person{ID: "1", Name: " ", Age: 151, Email: "bob", Nick: "abc"}
+	assert.Equal(t, []Violation{
+		{Field: "id", Rule: "empty"},
+		{Field: "name", Rule: "required"},
+		{Field: "age", Rule: "max", Param: "150"},
+		{Field: "email", Rule: "email"},
+		{Field: "address.city", Rule: "required"},
+		{Field: "Nick", Rule: "even"},
+	}, v.Struct(&p, Create))
+
+	// id проверяется только при создании, пустые строки - только правилом required
+	p = person{ID: "1", Name: "Ann", Address: address{City: "Omsk"}}
+	assert.Empty(t, v.Struct(p, Update))
+
+	p.Name = "Anna-Maria"
+	assert.Equal(t, []Violation{{Field: "name", Rule: "maxlen", Param: "5"}}, v.Struct(p, Update))
+
+	p.Name = "A1"
+	assert.Equal(t, []Violation{{Field: "name", Rule: "personname"}}, v.Struct(p, Update))
+}
+
+// Тестирование неизвестного правила
+func TestStructUnknownRule(t *testing.T) {
+	type bad struct {
+		Name string `validate:"nope"`
+	}
+	assert.Panics(t, func() { New().Struct(bad{Name: "x"}, Create) })
+}
+
+// Тестирование встроенных проверок строк
+func TestEmailAndPersonName(t *testing.T) {
+	for s, want := range map[string]bool{
+		"user@example.com":       true,
+		"first.last+tag@mail.ru": true,
+		"user@localhost":         false,
+		"user@example.":          false,
+		"Bob <bob@example.com>":  false,
+		"bob":                    false,
+		"bob@@example.com":       false,
+		" user@example.com":      false,
+	} {
+		assert.Equal(t, want, Email(s), s)
+	}
+
+	for s, want := range map[string]bool{
+		"Alice":            true,
+		"Анна-Мария":       true,
+		"O'Brien":          true,
+		"J. R. R. Tolkien": true,
+		"Zoë":              true,
+		"-Bob":             false,
+		"R2D2":             false,
+		"":                 false,
+		"Bob!":             false,
+	} {
+		assert.Equal(t, want, PersonName(s), s)
+	}
+}